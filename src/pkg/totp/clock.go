@@ -0,0 +1,38 @@
+package totp
+
+import "time"
+
+// Clock はコード生成に使用する現在時刻を提供する
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc は関数をClockとして扱うためのアダプター
+type ClockFunc func() time.Time
+
+// Now は関数を呼び出して現在時刻を返す
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// systemClock はシステム時刻を返すClock
+type systemClock struct{}
+
+// Now はシステムの現在時刻を返す
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock はシステム時刻を返すClock
+var SystemClock Clock = systemClock{}
+
+// WithOffset は基準のClockに固定のオフセットを加えた時刻を返すClockを作成する
+// 時計がずれているマシンでコードを補正するために使用する
+func WithOffset(base Clock, offset time.Duration) Clock {
+	if offset == 0 {
+		return base
+	}
+	return ClockFunc(func() time.Time {
+		return base.Now().Add(offset)
+	})
+}
@@ -0,0 +1,29 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockFunc(t *testing.T) {
+	fixed := time.Unix(1234567890, 0)
+	clock := ClockFunc(func() time.Time { return fixed })
+	assert.Equal(t, fixed, clock.Now())
+}
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	now := SystemClock.Now()
+	assert.False(t, now.Before(before))
+}
+
+func TestWithOffset(t *testing.T) {
+	fixed := time.Unix(1234567890, 0)
+	base := ClockFunc(func() time.Time { return fixed })
+
+	assert.Equal(t, fixed.Add(90*time.Second), WithOffset(base, 90*time.Second).Now())
+	assert.Equal(t, fixed.Add(-30*time.Second), WithOffset(base, -30*time.Second).Now())
+	assert.Equal(t, fixed, WithOffset(base, 0).Now())
+}
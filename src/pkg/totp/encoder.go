@@ -0,0 +1,160 @@
+package totp
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// 組み込みエンコーダー名
+const (
+	EncoderDecimal = "decimal" // 10進数（RFC 4226標準）
+	EncoderSteam   = "steam"   // Steam Guard
+)
+
+// steamAlphabet はSteam Guardコードで使用される文字集合
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+var (
+	// ErrUnknownEncoder は登録されていないエンコーダー名が指定された場合のエラー
+	ErrUnknownEncoder = errors.New("unknown OTP encoder")
+
+	// ErrInvalidAlphabet はAlphabetEncoderの文字集合が無効な場合のエラー
+	ErrInvalidAlphabet = errors.New("invalid encoder alphabet: must have at least 2 distinct ASCII characters")
+)
+
+// Encoder は動的切り詰め（Dynamic Truncation）後の31ビット値をコード文字列に変換する
+type Encoder interface {
+	// Encode はvalueをdigits文字のコードに変換する
+	Encode(value uint32, digits int) string
+}
+
+// ValidatingEncoder は設定を検証できるEncoder
+// RegisterEncoderやパラメータの検証で、コードを生成できない設定を事前に検出する
+type ValidatingEncoder interface {
+	Encoder
+	// Validate はエンコーダーの設定を検証する
+	Validate() error
+}
+
+// AppendEncoder はコードを既存のバイト列に追記できるEncoder
+// 実装するとGeneratorがアロケーションなしでコードを生成できる
+type AppendEncoder interface {
+	Encoder
+	// AppendEncode はvalueをdigits文字のコードに変換してdstに追記する
+	AppendEncode(dst []byte, value uint32, digits int) []byte
+}
+
+// DecimalEncoder は10進数のコードを生成する
+type DecimalEncoder struct{}
+
+// Encode はvalueを10^digitsで割った余りをゼロパディングした文字列を返す
+func (e DecimalEncoder) Encode(value uint32, digits int) string {
+	return string(e.AppendEncode(nil, value, digits))
+}
+
+// AppendEncode はvalueを10^digitsで割った余りをゼロパディングしてdstに追記する
+func (DecimalEncoder) AppendEncode(dst []byte, value uint32, digits int) []byte {
+	start := len(dst)
+	for range digits {
+		dst = append(dst, '0')
+	}
+	// 10桁を超える上位の桁は切り捨てられるため、uint32の値はそのまま下位の桁から埋める
+	v := value
+	for i := len(dst) - 1; i >= start && v > 0; i-- {
+		dst[i] = byte('0' + v%10)
+		v /= 10
+	}
+	return dst
+}
+
+// AlphabetEncoder は任意の文字集合で下位の桁から順にコードを生成する
+// Steam Guardなどの英数字コードに使用する（文字集合は2文字以上の重複しないASCII文字）
+type AlphabetEncoder struct {
+	Alphabet string
+}
+
+// NewAlphabetEncoder は文字集合を検証してAlphabetEncoderを作成する
+func NewAlphabetEncoder(alphabet string) (AlphabetEncoder, error) {
+	e := AlphabetEncoder{Alphabet: alphabet}
+	if err := e.Validate(); err != nil {
+		return AlphabetEncoder{}, err
+	}
+	return e, nil
+}
+
+// Validate は文字集合が2文字以上の重複しないASCII文字であることを検証する
+// 1文字の場合は常に同じコードになり、空の場合はコードを生成できないためエラーとする
+func (e AlphabetEncoder) Validate() error {
+	if len(e.Alphabet) < 2 {
+		return ErrInvalidAlphabet
+	}
+	var seen [128]bool
+	for i := range len(e.Alphabet) {
+		c := e.Alphabet[i]
+		if c >= 128 || seen[c] {
+			return ErrInvalidAlphabet
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// Encode はvalueをAlphabetの基数で変換し、下位の桁から順にdigits文字を並べた文字列を返す
+func (e AlphabetEncoder) Encode(value uint32, digits int) string {
+	return string(e.AppendEncode(nil, value, digits))
+}
+
+// AppendEncode はvalueをAlphabetの基数で変換し、下位の桁から順にdigits文字をdstに追記する
+// 文字集合が2文字未満の場合は何も追記しない（Validateで事前に検出する）
+func (e AlphabetEncoder) AppendEncode(dst []byte, value uint32, digits int) []byte {
+	base := uint32(len(e.Alphabet))
+	if base < 2 {
+		return dst
+	}
+	for range digits {
+		dst = append(dst, e.Alphabet[value%base])
+		value /= base
+	}
+	return dst
+}
+
+var (
+	encoders = map[string]Encoder{
+		EncoderDecimal: DecimalEncoder{},
+		EncoderSteam:   AlphabetEncoder{Alphabet: steamAlphabet},
+	}
+	encodersMu sync.RWMutex
+)
+
+// RegisterEncoder はエンコーダーを名前で登録する
+// 同じ名前が既に登録されている場合は上書きする
+// ValidatingEncoderの場合は設定を検証し、無効な場合は登録せずにエラーを返す
+func RegisterEncoder(name string, encoder Encoder) error {
+	if v, ok := encoder.(ValidatingEncoder); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[strings.ToLower(name)] = encoder
+	return nil
+}
+
+// LookupEncoder は名前からエンコーダーを取得する
+// 空文字列の場合は10進数エンコーダーを返す
+func LookupEncoder(name string) (Encoder, error) {
+	if name == "" {
+		return DecimalEncoder{}, nil
+	}
+
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	encoder, ok := encoders[strings.ToLower(name)]
+	if !ok {
+		return nil, ErrUnknownEncoder
+	}
+	return encoder, nil
+}
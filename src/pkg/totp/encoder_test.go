@@ -0,0 +1,117 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimalEncoder(t *testing.T) {
+	tests := []struct {
+		value    uint32
+		digits   int
+		expected string
+	}{
+		{value: 1284755224, digits: 6, expected: "755224"},
+		{value: 1284755224, digits: 8, expected: "84755224"},
+		{value: 1284755224, digits: 10, expected: "1284755224"},
+		{value: 5, digits: 6, expected: "000005"},
+		{value: 0, digits: 6, expected: "000000"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, DecimalEncoder{}.Encode(tt.value, tt.digits))
+	}
+}
+
+func TestSteamEncoder(t *testing.T) {
+	encoder, err := LookupEncoder(EncoderSteam)
+	require.NoError(t, err)
+
+	// 値0は先頭文字の繰り返し、26は2文字目が1つ進む
+	assert.Equal(t, "22222", encoder.Encode(0, 5))
+	assert.Equal(t, "23222", encoder.Encode(26, 5))
+	assert.Equal(t, "Y2222", encoder.Encode(25, 5))
+
+	// 生成されたコードはSteamの文字集合のみで構成される
+	params := Params{Algorithm: AlgorithmSHA1, Digits: 5, Period: 30, Encoder: encoder}
+	code, err := Generate("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", time.Unix(59, 0), params)
+	require.NoError(t, err)
+	assert.Len(t, code, 5)
+	for _, c := range code {
+		assert.Contains(t, steamAlphabet, string(c))
+	}
+}
+
+func TestLookupEncoder(t *testing.T) {
+	encoder, err := LookupEncoder("")
+	require.NoError(t, err)
+	assert.Equal(t, DecimalEncoder{}, encoder)
+
+	encoder, err = LookupEncoder("STEAM")
+	require.NoError(t, err)
+	assert.Equal(t, AlphabetEncoder{Alphabet: steamAlphabet}, encoder)
+
+	_, err = LookupEncoder("unknown")
+	assert.ErrorIs(t, err, ErrUnknownEncoder)
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	require.NoError(t, RegisterEncoder("binary", AlphabetEncoder{Alphabet: "01"}))
+
+	encoder, err := LookupEncoder("binary")
+	require.NoError(t, err)
+	assert.Equal(t, "1010", encoder.Encode(5, 4))
+
+	// 無効な文字集合のエンコーダーは登録しない
+	assert.ErrorIs(t, RegisterEncoder("constant", AlphabetEncoder{Alphabet: "0"}), ErrInvalidAlphabet)
+	_, err = LookupEncoder("constant")
+	assert.ErrorIs(t, err, ErrUnknownEncoder)
+}
+
+func TestNewAlphabetEncoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		wantErr  bool
+	}{
+		{name: "steam", alphabet: steamAlphabet},
+		{name: "binary", alphabet: "01"},
+		{name: "empty", alphabet: "", wantErr: true},
+		{name: "single", alphabet: "A", wantErr: true},
+		{name: "duplicate", alphabet: "ABA", wantErr: true},
+		{name: "non-ascii", alphabet: "あい", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoder, err := NewAlphabetEncoder(tt.alphabet)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidAlphabet)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.alphabet, encoder.Alphabet)
+		})
+	}
+
+	// 無効な文字集合でもパニックせず、パラメータの検証でエラーになる
+	assert.Empty(t, AlphabetEncoder{}.Encode(12345, 5))
+	params := Params{Algorithm: AlgorithmSHA1, Digits: 5, Period: 30, Encoder: AlphabetEncoder{}}
+	assert.ErrorIs(t, params.Validate(), ErrInvalidAlphabet)
+}
+
+func TestValidateWithEncoder(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Unix(1111111109, 0)
+	params := Params{Algorithm: AlgorithmSHA1, Digits: 5, Period: 30, Encoder: AlphabetEncoder{Alphabet: steamAlphabet}}
+
+	code, err := Generate(secret, now.Add(-30*time.Second), params)
+	require.NoError(t, err)
+
+	offset, err := Validate(secret, code, now, params, 1)
+	require.NoError(t, err)
+	assert.Equal(t, -1, offset)
+}
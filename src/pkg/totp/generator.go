@@ -0,0 +1,163 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCodeLen はコード比較用バッファの長さ（MaxDigits以上）
+const maxCodeLen = 16
+
+// codeCacheSize は生成済みコードを保持する件数（前・現在・次のステップ）
+const codeCacheSize = 3
+
+// cachedCode は生成済みのコードとそのカウンター値
+type cachedCode struct {
+	counter uint64
+	code    string
+}
+
+// Generator はデコード済みの鍵とHMACを保持し、同じシークレットのコードを繰り返し生成する
+// 毎秒すべてのエントリのコードを再計算するリスト表示向けで、生成時にアロケーションを行わない
+// 複数のゴルーチンから同時に使用できる
+type Generator struct {
+	params  Params
+	encoder Encoder
+
+	mu      sync.Mutex
+	mac     hash.Hash
+	message [8]byte
+	sum     []byte
+
+	// 直近に生成したコード（同じステップ内の再生成で文字列を再利用する）
+	codes    [codeCacheSize]cachedCode
+	nextSlot int
+}
+
+// NewGenerator はシークレットをデコードしてGeneratorを作成する
+// paramsはHOTPとして検証し、TOTPとしての更新間隔は生成時に検証する
+func NewGenerator(secret string, params Params) (*Generator, error) {
+	if err := params.ValidateForHOTP(); err != nil {
+		return nil, err
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	h, _ := params.Algorithm.hash()
+	mac := hmac.New(h, key)
+	return &Generator{
+		params:  params,
+		encoder: params.encoder(),
+		mac:     mac,
+		sum:     make([]byte, 0, mac.Size()),
+	}, nil
+}
+
+// Params はGeneratorのコード生成パラメータを返す
+func (g *Generator) Params() Params {
+	return g.params
+}
+
+// At はtimestamp時点のTOTPコードを生成する
+// 直近に生成したタイムステップの場合は生成済みのコードを返すため、アロケーションを行わない
+func (g *Generator) At(timestamp time.Time) (string, error) {
+	counter, err := g.params.counter(timestamp)
+	if err != nil {
+		return "", err
+	}
+	return g.AtCounter(counter), nil
+}
+
+// AppendAt はtimestamp時点のTOTPコードをdstに追記する
+func (g *Generator) AppendAt(dst []byte, timestamp time.Time) ([]byte, error) {
+	counter, err := g.params.counter(timestamp)
+	if err != nil {
+		return dst, err
+	}
+	return g.AppendAtCounter(dst, counter), nil
+}
+
+// AtCounter はカウンター値のHOTPコードを生成する
+func (g *Generator) AtCounter(counter uint64) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, c := range g.codes {
+		if c.code != "" && c.counter == counter {
+			return c.code
+		}
+	}
+
+	var buf [maxCodeLen]byte
+	code := string(g.appendCode(buf[:0], counter))
+	g.codes[g.nextSlot] = cachedCode{counter: counter, code: code}
+	g.nextSlot = (g.nextSlot + 1) % codeCacheSize
+	return code
+}
+
+// AppendAtCounter はカウンター値のHOTPコードをdstに追記する
+func (g *Generator) AppendAtCounter(dst []byte, counter uint64) []byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.appendCode(dst, counter)
+}
+
+// Validate はTOTPコードを検証し、一致したタイムステップのオフセットを返す
+// 検索順序とオフセットの意味はパッケージ関数のValidateと同じ
+func (g *Generator) Validate(code string, timestamp time.Time, window int) (int, error) {
+	counter, err := g.params.counter(timestamp)
+	if err != nil {
+		return 0, err
+	}
+	return g.ValidateCounter(code, counter, window)
+}
+
+// ValidateCounter はHOTPコードを検証し、一致したカウンターのオフセットを返す
+// 検索順序とオフセットの意味はパッケージ関数のValidateHOTPと同じ
+func (g *Generator) ValidateCounter(code string, counter uint64, window int) (int, error) {
+	// 読み上げ時の区切りスペースを除去
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+
+	var buf [maxCodeLen]byte
+	for _, offset := range windowOffsets(window) {
+		if offset < 0 && uint64(-offset) > counter {
+			continue
+		}
+		candidate := g.AppendAtCounter(buf[:0], counter+uint64(offset))
+		if subtle.ConstantTimeCompare(candidate, []byte(code)) == 1 {
+			return offset, nil
+		}
+	}
+	return 0, ErrCodeMismatch
+}
+
+// appendCode はカウンター値のコードをdstに追記する（呼び出し元でmuをロックすること）
+func (g *Generator) appendCode(dst []byte, counter uint64) []byte {
+	value := g.truncate(counter)
+	if enc, ok := g.encoder.(AppendEncoder); ok {
+		return enc.AppendEncode(dst, value, g.params.Digits)
+	}
+	return append(dst, g.encoder.Encode(value, g.params.Digits)...)
+}
+
+// truncate はカウンター値のHMACを計算し、動的切り詰め後の31ビット値を返す（呼び出し元でmuをロックすること）
+func (g *Generator) truncate(counter uint64) uint32 {
+	// カウンターをビッグエンディアンでバイト列に変換してHMACを計算
+	binary.BigEndian.PutUint64(g.message[:], counter)
+	g.mac.Reset()
+	g.mac.Write(g.message[:])
+	sum := g.mac.Sum(g.sum[:0])
+
+	// Dynamic Truncation
+	// MD5のHMACは16バイトのため、4バイトを読み取れないオフセットは末尾の4バイトに切り詰める
+	offset := min(int(sum[len(sum)-1]&0x0f), len(sum)-4)
+	return binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+}
@@ -0,0 +1,180 @@
+package totp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret はRFC 4226/6238のテストシークレット "12345678901234567890" のBase32表現
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerator(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	// RFC 6238 テストベクター（同じGeneratorを繰り返し使用）
+	for unix, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := g.At(time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, "unix=%d", unix)
+	}
+
+	// RFC 4226 テストベクター
+	assert.Equal(t, "755224", g.AtCounter(0))
+	assert.Equal(t, "520489", g.AtCounter(9))
+}
+
+func TestGeneratorAppend(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	dst, err := g.AppendAt([]byte("code="), time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "code=287082", string(dst))
+
+	assert.Equal(t, "755224", string(g.AppendAtCounter(nil, 0)))
+}
+
+func TestGeneratorValidate(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	offset, err := g.Validate("287 082", time.Unix(89, 0), 1)
+	require.NoError(t, err)
+	assert.Equal(t, -1, offset)
+
+	offset, err = g.ValidateCounter("520489", 7, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, offset)
+
+	_, err = g.ValidateCounter("000000", 7, 2)
+	assert.ErrorIs(t, err, ErrCodeMismatch)
+}
+
+func TestGeneratorInvalidInput(t *testing.T) {
+	_, err := NewGenerator("invalid!@#$", DefaultParams())
+	require.Error(t, err)
+
+	_, err = NewGenerator(rfcSecret, Params{Algorithm: "MD4", Digits: 6, Period: 30})
+	require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+
+	// HOTP用に作成したGeneratorでTOTPを生成するとエラーになる
+	g, err := NewGenerator(rfcSecret, Params{Algorithm: AlgorithmSHA1, Digits: 6})
+	require.NoError(t, err)
+	_, err = g.At(time.Unix(59, 0))
+	require.ErrorIs(t, err, ErrInvalidPeriod)
+	assert.Equal(t, "755224", g.AtCounter(0))
+}
+
+// plainEncoder はAppendEncoderを実装しないテスト用のEncoder
+type plainEncoder struct{}
+
+func (plainEncoder) Encode(_ uint32, digits int) string {
+	return string(make([]byte, digits))
+}
+
+func TestGeneratorPlainEncoder(t *testing.T) {
+	params := DefaultParams()
+	params.Encoder = plainEncoder{}
+	g, err := NewGenerator(rfcSecret, params)
+	require.NoError(t, err)
+	assert.Len(t, g.AtCounter(0), 6)
+}
+
+func TestGeneratorConcurrent(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				assert.Equal(t, "755224", g.AtCounter(0))
+				assert.Equal(t, "287082", g.AtCounter(1))
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestGeneratorZeroAlloc(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	buf := make([]byte, 0, maxCodeLen)
+	timestamp := time.Unix(59, 0)
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = g.AppendAt(buf[:0], timestamp)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestGeneratorAtReusesCode(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	_, err = g.At(time.Unix(30, 0))
+	require.NoError(t, err)
+
+	// 同じタイムステップ内の再生成はアロケーションを行わない
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = g.At(time.Unix(59, 0))
+	})
+	assert.Zero(t, allocs)
+
+	// ステップが変わると新しいコードを生成する
+	code, err := g.At(time.Unix(60, 0))
+	require.NoError(t, err)
+	assert.NotEqual(t, "287082", code)
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	timestamp := time.Unix(1234567890, 0)
+	params := DefaultParams()
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = Generate(rfcSecret, timestamp, params)
+	}
+}
+
+func BenchmarkGeneratorAt(b *testing.B) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(b, err)
+	timestamp := time.Unix(1234567890, 0)
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = g.At(timestamp)
+	}
+}
+
+// BenchmarkGeneratorAtNewStep は毎回タイムステップが変わる場合の生成コストを計測する
+func BenchmarkGeneratorAtNewStep(b *testing.B) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(b, err)
+	var step int64
+	b.ReportAllocs()
+	for b.Loop() {
+		step++
+		_, _ = g.At(time.Unix(step*30, 0))
+	}
+}
+
+func BenchmarkGeneratorAppendAt(b *testing.B) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(b, err)
+	timestamp := time.Unix(1234567890, 0)
+	buf := make([]byte, 0, maxCodeLen)
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = g.AppendAt(buf[:0], timestamp)
+	}
+}
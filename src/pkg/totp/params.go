@@ -0,0 +1,154 @@
+package totp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"strings"
+	"time"
+)
+
+// Algorithm はHMACに使用するハッシュアルゴリズムを表す
+type Algorithm string
+
+// 対応アルゴリズム
+const (
+	AlgorithmSHA1   Algorithm = "SHA1"
+	AlgorithmSHA256 Algorithm = "SHA256"
+	AlgorithmSHA512 Algorithm = "SHA512"
+	AlgorithmMD5    Algorithm = "MD5" // 互換性のためのみ（Google Authenticatorのmigration形式で定義されている）
+)
+
+// パラメータのデフォルト値と制限
+const (
+	DefaultDigits = 6  // デフォルトの桁数
+	DefaultPeriod = 30 // デフォルトの更新間隔（秒）
+
+	MinDigits = 4  // 最小桁数
+	MaxDigits = 10 // 最大桁数（31ビットの切り詰め値を表現できる桁数）
+)
+
+var (
+	// ErrUnsupportedAlgorithm は未対応のアルゴリズムが指定された場合のエラー
+	ErrUnsupportedAlgorithm = errors.New("unsupported OTP algorithm")
+
+	// ErrInvalidDigits は桁数が範囲外の場合のエラー
+	ErrInvalidDigits = errors.New("invalid OTP digits: must be between 4 and 10")
+
+	// ErrInvalidPeriod は更新間隔が0以下の場合のエラー
+	ErrInvalidPeriod = errors.New("invalid OTP period: must be positive")
+
+	// ErrBeforeT0 は時刻がTOTPの起点（T0）より前の場合のエラー
+	ErrBeforeT0 = errors.New("timestamp is before the TOTP start time (T0)")
+)
+
+// ParseAlgorithm は文字列をAlgorithmに変換する（大文字小文字は区別しない）
+func ParseAlgorithm(s string) (Algorithm, error) {
+	algorithm := Algorithm(strings.ToUpper(strings.TrimSpace(s)))
+	if _, err := algorithm.hash(); err != nil {
+		return "", err
+	}
+	return algorithm, nil
+}
+
+// hash はアルゴリズムに対応するハッシュ関数のコンストラクタを返す
+func (a Algorithm) hash() (func() hash.Hash, error) {
+	switch a {
+	case AlgorithmSHA1:
+		return sha1.New, nil
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSHA512:
+		return sha512.New, nil
+	case AlgorithmMD5:
+		return md5.New, nil
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// Params はOTPコード生成のパラメータを表す
+type Params struct {
+	Algorithm Algorithm // HMACアルゴリズム
+	Digits    int       // コードの桁数
+	Period    int       // 更新間隔（秒、TOTPのみ）
+	T0        int64     // カウントを開始するUnix時刻（秒、TOTPのみ、RFC 6238のT0）
+	Encoder   Encoder   // コードのエンコーダー（nilの場合は10進数）
+}
+
+// DefaultParams はデフォルトのパラメータ（SHA1, 6桁, 30秒）を返す
+func DefaultParams() Params {
+	return Params{
+		Algorithm: AlgorithmSHA1,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+}
+
+// Validate はTOTP用のパラメータを検証する
+func (p Params) Validate() error {
+	if err := p.ValidateForHOTP(); err != nil {
+		return err
+	}
+	if p.Period <= 0 {
+		return ErrInvalidPeriod
+	}
+	return nil
+}
+
+// ValidateForHOTP はHOTP用のパラメータ（アルゴリズム・桁数・エンコーダーの設定）を検証する
+// HOTPは更新間隔を使用しないため、Periodは検証しない
+func (p Params) ValidateForHOTP() error {
+	if _, err := p.Algorithm.hash(); err != nil {
+		return err
+	}
+	if p.Digits < MinDigits || p.Digits > MaxDigits {
+		return ErrInvalidDigits
+	}
+	if v, ok := p.Encoder.(ValidatingEncoder); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// RemainingSeconds はtimestamp時点から次のコード更新までの残り秒数を返す
+// Periodが0以下の場合は0を返す
+func (p Params) RemainingSeconds(timestamp time.Time) int {
+	if p.Period <= 0 {
+		return 0
+	}
+	period := int64(p.Period)
+	elapsed := (timestamp.Unix() - p.T0) % period
+	if elapsed < 0 {
+		elapsed += period
+	}
+	return int(period - elapsed)
+}
+
+// counter はtimestampをT0からの時間カウンターに変換する
+func (p Params) counter(timestamp time.Time) (uint64, error) {
+	if p.Period <= 0 {
+		return 0, ErrInvalidPeriod
+	}
+	elapsed := timestamp.Unix() - p.T0
+	if elapsed < 0 {
+		return 0, ErrBeforeT0
+	}
+	return uint64(elapsed) / uint64(p.Period), nil
+}
+
+// stepStart はカウンター値のタイムステップが始まる時刻を返す
+func (p Params) stepStart(counter uint64) time.Time {
+	return time.Unix(p.T0+int64(counter)*int64(p.Period), 0)
+}
+
+// encoder はパラメータのエンコーダーを返す（nilの場合は10進数）
+func (p Params) encoder() Encoder {
+	if p.Encoder == nil {
+		return DecimalEncoder{}
+	}
+	return p.Encoder
+}
@@ -0,0 +1,46 @@
+package totp
+
+import "time"
+
+// Snapshot はある時点のTOTPコードと有効期間、前後のタイムステップのコードを表す
+// 同じ時刻から計算されるため、ステップの境界付近でもコードと残り時間が食い違わない
+type Snapshot struct {
+	Time       time.Time // 基準時刻
+	Counter    uint64    // 基準時刻のタイムステップ
+	Code       string    // 現在のコード
+	Previous   string    // 前のステップのコード（Counterが0の場合は空）
+	Next       string    // 次のステップのコード
+	ValidFrom  time.Time // 現在のコードの有効開始時刻
+	ValidUntil time.Time // 現在のコードの有効終了時刻（次のステップの開始時刻）
+}
+
+// Remaining は基準時刻から現在のコードの有効終了までの時間を返す
+func (s Snapshot) Remaining() time.Duration {
+	return s.ValidUntil.Sub(s.Time)
+}
+
+// RemainingSeconds は基準時刻から次のコード更新までの残り秒数を返す（RemainingSecondsと同じ値）
+func (s Snapshot) RemainingSeconds() int {
+	return int(s.ValidUntil.Unix() - s.Time.Unix())
+}
+
+// Snapshot はtimestamp時点のTOTPコードと有効期間、前後のコードを生成する
+func (g *Generator) Snapshot(timestamp time.Time) (Snapshot, error) {
+	counter, err := g.params.counter(timestamp)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{
+		Time:       timestamp,
+		Counter:    counter,
+		Code:       g.AtCounter(counter),
+		Next:       g.AtCounter(counter + 1),
+		ValidFrom:  g.params.stepStart(counter),
+		ValidUntil: g.params.stepStart(counter + 1),
+	}
+	if counter > 0 {
+		snapshot.Previous = g.AtCounter(counter - 1)
+	}
+	return snapshot, nil
+}
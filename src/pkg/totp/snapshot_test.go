@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratorSnapshot(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	// RFC 4226 テストベクター: カウンター0〜2のコード
+	snapshot, err := g.Snapshot(time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), snapshot.Counter)
+	assert.Equal(t, "287082", snapshot.Code)
+	assert.Equal(t, "755224", snapshot.Previous)
+	assert.Equal(t, "359152", snapshot.Next)
+	assert.Equal(t, time.Unix(30, 0), snapshot.ValidFrom)
+	assert.Equal(t, time.Unix(60, 0), snapshot.ValidUntil)
+	assert.Equal(t, time.Second, snapshot.Remaining())
+	assert.Equal(t, 1, snapshot.RemainingSeconds())
+}
+
+func TestGeneratorSnapshot_FirstStep(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	// 最初のステップには前のコードがない
+	snapshot, err := g.Snapshot(time.Unix(0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "755224", snapshot.Code)
+	assert.Empty(t, snapshot.Previous)
+	assert.Equal(t, "287082", snapshot.Next)
+	assert.Equal(t, 30, snapshot.RemainingSeconds())
+}
+
+func TestGeneratorSnapshot_MatchesRemainingSeconds(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	for unix := int64(1111111080); unix < 1111111140; unix++ {
+		timestamp := time.Unix(unix, 500_000_000)
+		snapshot, err := g.Snapshot(timestamp)
+		require.NoError(t, err)
+		assert.Equal(t, RemainingSeconds(timestamp, DefaultPeriod), snapshot.RemainingSeconds(), "unix=%d", unix)
+
+		code, err := Generate(rfcSecret, timestamp, DefaultParams())
+		require.NoError(t, err)
+		assert.Equal(t, code, snapshot.Code, "unix=%d", unix)
+	}
+}
+
+func TestGeneratorSnapshot_ZeroAlloc(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, DefaultParams())
+	require.NoError(t, err)
+
+	_, err = g.Snapshot(time.Unix(59, 0))
+	require.NoError(t, err)
+
+	// 前・現在・次のコードはキャッシュされるため、同じステップ内ではアロケーションを行わない
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = g.Snapshot(time.Unix(45, 0))
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestGeneratorSnapshot_InvalidPeriod(t *testing.T) {
+	g, err := NewGenerator(rfcSecret, Params{Algorithm: AlgorithmSHA1, Digits: 6})
+	require.NoError(t, err)
+
+	_, err = g.Snapshot(time.Unix(59, 0))
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+}
+
+func TestGeneratorSnapshot_T0(t *testing.T) {
+	params := DefaultParams()
+	params.T0 = 1000
+	g, err := NewGenerator(rfcSecret, params)
+	require.NoError(t, err)
+
+	snapshot, err := g.Snapshot(time.Unix(1059, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", snapshot.Code)
+	assert.Equal(t, time.Unix(1030, 0), snapshot.ValidFrom)
+	assert.Equal(t, time.Unix(1060, 0), snapshot.ValidUntil)
+	assert.Equal(t, params.RemainingSeconds(time.Unix(1059, 0)), snapshot.RemainingSeconds())
+}
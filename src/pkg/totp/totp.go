@@ -1,72 +1,87 @@
-// Package totp はRFC 6238準拠のTOTP生成機能を提供する
+// Package totp はRFC 6238準拠のTOTP生成機能およびRFC 4226準拠のHOTP生成機能を提供する
 package totp
 
 import (
-	"crypto/hmac"
-	"crypto/sha1"
-	"crypto/sha256"
-	"crypto/sha512"
 	"encoding/base32"
-	"encoding/binary"
-	"fmt"
-	"hash"
+	"errors"
 	"strings"
 	"time"
 )
 
+// ErrCodeMismatch は検証ウィンドウ内のどのステップでもコードが一致しなかった場合のエラー
+var ErrCodeMismatch = errors.New("code does not match within the validation window")
+
 // Generate はTOTPコードを生成する
-func Generate(secret string, timestamp time.Time, digits int, period int, algorithm string) (string, error) {
-	// Base32デコード
-	secret = strings.ToUpper(strings.TrimSpace(secret))
-	// パディングを追加（必要な場合）
-	if m := len(secret) % 8; m != 0 {
-		secret += strings.Repeat("=", 8-m)
+// 同じシークレットで繰り返し生成する場合はNewGeneratorを使用する
+func Generate(secret string, timestamp time.Time, params Params) (string, error) {
+	if err := params.Validate(); err != nil {
+		return "", err
 	}
 
-	key, err := base32.StdEncoding.DecodeString(secret)
+	g, err := NewGenerator(secret, params)
 	if err != nil {
 		return "", err
 	}
+	return g.At(timestamp)
+}
 
-	// 時間カウンターを計算
-	counter := uint64(timestamp.Unix()) / uint64(period)
+// GenerateHOTP はカウンター値からHOTPコードを生成する（params.Periodは使用しない）
+func GenerateHOTP(secret string, counter uint64, params Params) (string, error) {
+	g, err := NewGenerator(secret, params)
+	if err != nil {
+		return "", err
+	}
+	return g.AtCounter(counter), nil
+}
 
-	// カウンターをビッグエンディアンでバイト列に変換
-	counterBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(counterBytes, counter)
+// Validate はTOTPコードを検証し、一致したタイムステップのオフセットを返す
+// 現在のステップから近い順に ±window ステップの範囲を検索し、
+// オフセットが負の場合はコードが過去のステップ（相手の時計が遅れている）であることを示す
+func Validate(secret, code string, timestamp time.Time, params Params, window int) (int, error) {
+	if err := params.Validate(); err != nil {
+		return 0, err
+	}
 
-	// HMACを計算
-	var h func() hash.Hash
-	switch strings.ToUpper(algorithm) {
-	case "SHA256":
-		h = sha256.New
-	case "SHA512":
-		h = sha512.New
-	default:
-		h = sha1.New
+	g, err := NewGenerator(secret, params)
+	if err != nil {
+		return 0, err
 	}
+	return g.Validate(code, timestamp, window)
+}
 
-	mac := hmac.New(h, key)
-	mac.Write(counterBytes)
-	sum := mac.Sum(nil)
+// ValidateHOTP はHOTPコードを検証し、一致したカウンターのオフセットを返す
+// counterから近い順に ±window の範囲を検索する（0未満のカウンターは対象外）
+func ValidateHOTP(secret, code string, counter uint64, params Params, window int) (int, error) {
+	g, err := NewGenerator(secret, params)
+	if err != nil {
+		return 0, err
+	}
+	return g.ValidateCounter(code, counter, window)
+}
 
-	// Dynamic Truncation
-	offset := sum[len(sum)-1] & 0x0f
-	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+// RemainingSeconds はtimestamp時点から次のコード更新までの残り秒数を返す
+// periodが0以下の場合は0を返す
+func RemainingSeconds(timestamp time.Time, period int) int {
+	return Params{Period: period}.RemainingSeconds(timestamp)
+}
 
-	// 桁数に合わせてmod
-	mod := uint32(1)
-	for range digits {
-		mod *= 10
+// windowOffsets は検証対象のオフセットを0に近い順に返す（0, -1, +1, -2, +2, ...）
+func windowOffsets(window int) []int {
+	window = max(window, 0)
+	offsets := make([]int, 0, 2*window+1)
+	offsets = append(offsets, 0)
+	for i := 1; i <= window; i++ {
+		offsets = append(offsets, -i, i)
 	}
-	code = code % mod
-
-	// 桁数に合わせてゼロパディング
-	format := fmt.Sprintf("%%0%dd", digits)
-	return fmt.Sprintf(format, code), nil
+	return offsets
 }
 
-// RemainingSeconds は次のコード更新までの残り秒数を返す
-func RemainingSeconds(period int) int {
-	return period - int(time.Now().Unix()%int64(period))
+// decodeSecret はBase32シークレットをデコードする
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimSpace(secret))
+	// パディングを追加（必要な場合）
+	if m := len(secret) % 8; m != 0 {
+		secret += strings.Repeat("=", 8-m)
+	}
+	return base32.StdEncoding.DecodeString(secret)
 }
@@ -48,7 +48,7 @@ func TestGenerate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			code, err := Generate(secret, tt.timestamp, 6, 30, "SHA1")
+			code, err := Generate(secret, tt.timestamp, DefaultParams())
 			require.NoError(t, err)
 			assert.Equal(t, tt.expected, code)
 		})
@@ -59,13 +59,13 @@ func TestGenerateWithPadding(t *testing.T) {
 	// パディングなしのシークレットでもテスト
 	secret := "JBSWY3DPEHPK3PXP" // 一般的なテストシークレット
 
-	code, err := Generate(secret, time.Now(), 6, 30, "SHA1")
+	code, err := Generate(secret, time.Now(), DefaultParams())
 	require.NoError(t, err)
 	assert.Len(t, code, 6)
 }
 
 func TestGenerateInvalidSecret(t *testing.T) {
-	_, err := Generate("invalid!@#$", time.Now(), 6, 30, "SHA1")
+	_, err := Generate("invalid!@#$", time.Now(), DefaultParams())
 	assert.Error(t, err)
 }
 
@@ -73,10 +73,10 @@ func TestGenerateDifferentAlgorithms(t *testing.T) {
 	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
 	timestamp := time.Unix(59, 0)
 
-	algorithms := []string{"SHA1", "SHA256", "SHA512"}
+	algorithms := []Algorithm{AlgorithmSHA1, AlgorithmSHA256, AlgorithmSHA512}
 	for _, algo := range algorithms {
-		t.Run(algo, func(t *testing.T) {
-			code, err := Generate(secret, timestamp, 6, 30, algo)
+		t.Run(string(algo), func(t *testing.T) {
+			code, err := Generate(secret, timestamp, Params{Algorithm: algo, Digits: 6, Period: 30})
 			require.NoError(t, err)
 			assert.Len(t, code, 6)
 		})
@@ -97,7 +97,7 @@ func TestGenerateDifferentDigits(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
-			code, err := Generate(secret, timestamp, tt.digits, 30, "SHA1")
+			code, err := Generate(secret, timestamp, Params{Algorithm: AlgorithmSHA1, Digits: tt.digits, Period: 30})
 			require.NoError(t, err)
 			assert.Len(t, code, tt.expected)
 		})
@@ -105,7 +105,249 @@ func TestGenerateDifferentDigits(t *testing.T) {
 }
 
 func TestRemainingSeconds(t *testing.T) {
-	remaining := RemainingSeconds(30)
+	remaining := RemainingSeconds(time.Now(), 30)
 	assert.GreaterOrEqual(t, remaining, 1)
 	assert.LessOrEqual(t, remaining, 30)
 }
+
+func TestRemainingSecondsBoundary(t *testing.T) {
+	tests := []struct {
+		unix     int64
+		expected int
+	}{
+		{unix: 0, expected: 30},
+		{unix: 29, expected: 1},
+		{unix: 30, expected: 30},
+		{unix: 59, expected: 1},
+		{unix: 1111111109, expected: 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, RemainingSeconds(time.Unix(tt.unix, 0), 30), "unix=%d", tt.unix)
+	}
+}
+
+func TestGenerateHOTP(t *testing.T) {
+	// RFC 4226 テストベクター
+	// https://datatracker.ietf.org/doc/html/rfc4226#page-32
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, want := range expected {
+		code, err := GenerateHOTP(secret, uint64(counter), DefaultParams())
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "counter=%d", counter)
+	}
+}
+
+func TestGenerateHOTPInvalidSecret(t *testing.T) {
+	_, err := GenerateHOTP("invalid!@#$", 0, DefaultParams())
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		offset int
+	}{
+		{name: "current step", at: now, offset: 0},
+		{name: "previous step", at: now.Add(-30 * time.Second), offset: -1},
+		{name: "next step", at: now.Add(30 * time.Second), offset: 1},
+		{name: "three steps ahead", at: now.Add(90 * time.Second), offset: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Generate(secret, tt.at, DefaultParams())
+			require.NoError(t, err)
+
+			offset, err := Validate(secret, code, now, DefaultParams(), 3)
+			require.NoError(t, err)
+			assert.Equal(t, tt.offset, offset)
+		})
+	}
+}
+
+func TestValidateOutsideWindow(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Unix(1111111109, 0)
+
+	code, err := Generate(secret, now.Add(5*30*time.Second), DefaultParams())
+	require.NoError(t, err)
+
+	_, err = Validate(secret, code, now, DefaultParams(), 2)
+	assert.ErrorIs(t, err, ErrCodeMismatch)
+}
+
+func TestValidateAcceptsSpacedCode(t *testing.T) {
+	// RFC 6238 テストベクター: Unix time 59 → 287082
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	offset, err := Validate(secret, " 287 082 ", time.Unix(59, 0), DefaultParams(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, offset)
+}
+
+func TestValidateHOTP(t *testing.T) {
+	// RFC 4226 テストベクター: カウンター=0 → 755224
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	// カウンター0より前は検索しない
+	offset, err := ValidateHOTP(secret, "755224", 0, DefaultParams(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, offset)
+
+	offset, err = ValidateHOTP(secret, "755224", 2, DefaultParams(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, -2, offset)
+
+	_, err = ValidateHOTP(secret, "000000", 2, DefaultParams(), 2)
+	assert.ErrorIs(t, err, ErrCodeMismatch)
+}
+
+func TestValidateInvalidSecret(t *testing.T) {
+	_, err := Validate("invalid!@#$", "000000", time.Unix(59, 0), DefaultParams(), 1)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCodeMismatch)
+}
+
+func TestGenerateInvalidParams(t *testing.T) {
+	secret := "JBSWY3DPEHPK3PXP"
+
+	tests := []struct {
+		name   string
+		params Params
+		err    error
+	}{
+		{
+			name:   "zero period",
+			params: Params{Algorithm: AlgorithmSHA1, Digits: 6, Period: 0},
+			err:    ErrInvalidPeriod,
+		},
+		{
+			name:   "negative period",
+			params: Params{Algorithm: AlgorithmSHA1, Digits: 6, Period: -30},
+			err:    ErrInvalidPeriod,
+		},
+		{
+			name:   "too many digits",
+			params: Params{Algorithm: AlgorithmSHA1, Digits: 11, Period: 30},
+			err:    ErrInvalidDigits,
+		},
+		{
+			name:   "too few digits",
+			params: Params{Algorithm: AlgorithmSHA1, Digits: 0, Period: 30},
+			err:    ErrInvalidDigits,
+		},
+		{
+			name:   "unknown algorithm",
+			params: Params{Algorithm: "SHA3", Digits: 6, Period: 30},
+			err:    ErrUnsupportedAlgorithm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate(secret, time.Now(), tt.params)
+			require.ErrorIs(t, err, tt.err)
+
+			_, err = Validate(secret, "000000", time.Now(), tt.params, 1)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestGenerateHOTPIgnoresPeriod(t *testing.T) {
+	// HOTPでは更新間隔を使用しないため、0でもエラーにならない
+	params := Params{Algorithm: AlgorithmSHA1, Digits: 6, Period: 0}
+	code, err := GenerateHOTP("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 0, params)
+	require.NoError(t, err)
+	assert.Equal(t, "755224", code)
+}
+
+func TestGenerateTenDigits(t *testing.T) {
+	// 10桁はuint32の範囲を超える剰余になるためオーバーフローしないことを確認
+	// RFC 4226 カウンター=0 の切り詰め値は 1284755224
+	params := Params{Algorithm: AlgorithmSHA1, Digits: 10, Period: 30}
+	code, err := GenerateHOTP("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 0, params)
+	require.NoError(t, err)
+	assert.Equal(t, "1284755224", code)
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Algorithm
+	}{
+		{"SHA1", AlgorithmSHA1},
+		{"sha256", AlgorithmSHA256},
+		{" Sha512 ", AlgorithmSHA512},
+		{"md5", AlgorithmMD5},
+	}
+	for _, tt := range tests {
+		algo, err := ParseAlgorithm(tt.input)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, algo)
+	}
+
+	_, err := ParseAlgorithm("MD4")
+	require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	_, err = ParseAlgorithm("")
+	require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+func TestGenerateHOTPMD5(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	params := Params{Algorithm: AlgorithmMD5, Digits: 6}
+
+	// HMAC-MD5は16バイトのため、オフセット13〜15は末尾の4バイトに切り詰められる
+	for counter, expected := range []string{"671151", "532013", "154574", "848120", "208349"} {
+		code, err := GenerateHOTP(secret, uint64(counter), params)
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, "counter=%d", counter)
+	}
+}
+
+func TestRemainingSecondsInvalidPeriod(t *testing.T) {
+	assert.Equal(t, 0, RemainingSeconds(time.Now(), 0))
+}
+
+func TestGenerateWithT0(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	params := DefaultParams()
+	params.T0 = 1000
+
+	// T0からの経過時間でカウンターを計算する（1059 - 1000 = 59 → RFC 6238の59秒と同じコード）
+	code, err := Generate(secret, time.Unix(1059, 0), params)
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	offset, err := Validate(secret, "287082", time.Unix(1060, 0), params, 1)
+	require.NoError(t, err)
+	assert.Equal(t, -1, offset)
+
+	// T0より前の時刻はエラー
+	_, err = Generate(secret, time.Unix(999, 0), params)
+	require.ErrorIs(t, err, ErrBeforeT0)
+
+	// T0が0の場合は従来どおり
+	code, err = Generate(secret, time.Unix(59, 0), DefaultParams())
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestParamsRemainingSecondsWithT0(t *testing.T) {
+	params := Params{Period: 30, T0: 10}
+	assert.Equal(t, 30, params.RemainingSeconds(time.Unix(10, 0)))
+	assert.Equal(t, 1, params.RemainingSeconds(time.Unix(39, 0)))
+	assert.Equal(t, 30, params.RemainingSeconds(time.Unix(40, 0)))
+
+	// T0より前の時刻でも次のステップ境界までの秒数を返す
+	assert.Equal(t, 10, params.RemainingSeconds(time.Unix(0, 0)))
+}
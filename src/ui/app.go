@@ -1,6 +1,9 @@
 package ui
 
 import (
+	"path/filepath"
+	"time"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
@@ -8,6 +11,7 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/nktmys/winticator/src/assets"
+	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/nktmys/winticator/src/ui/custom"
 	"github.com/nktmys/winticator/src/usecase/clipboard"
 	"github.com/nktmys/winticator/src/usecase/preferences"
@@ -42,6 +46,9 @@ type App struct {
 	// TOTPリストビュー
 	totpListView *totpListTab
 
+	// 設定ビュー
+	settingsView *settingsTab
+
 	// ツールバーボタン
 	totpButton     *widget.Button
 	settingsButton *widget.Button
@@ -60,15 +67,62 @@ func NewApp() *App {
 	fyneApp.Settings().SetTheme(custom.NewTheme(variant))
 
 	// TOTPストアを作成
-	store := totpstore.New(preferences)
+	store := newTOTPStore(fyneApp, preferences)
 
-	return &App{
+	a := &App{
 		fyneApp:     fyneApp,
 		preferences: preferences,
 		clipboard:   clipboard,
 		totpStore:   store,
 		pages:       make(map[pageID]fyne.CanvasObject),
 	}
+
+	// 保存された時刻オフセットをコード生成に反映
+	a.applyTimeOffset()
+
+	return a
+}
+
+// newTOTPStore は保存データファイルに保存するTOTPストアを作成する
+// Preferencesに保存されていた既存のデータは読み込み時に保存データファイルに移行する
+// ユーザーが指定した保存先のファイルが見つからない場合は、新しい保存データとして扱わず復旧モードにする
+func newTOTPStore(fyneApp fyne.App, prefs *preferences.Manager) *totpstore.Store {
+	path := prefs.GetVaultPath()
+	custom := path != ""
+	if !custom {
+		path = defaultVaultPath(fyneApp)
+	}
+
+	backend := totpstore.NewFileBackend(path)
+	backend.SetRequired(custom)
+
+	store := totpstore.NewWithBackend(backend)
+	store.MigrateFrom(totpstore.NewPreferencesBackend(prefs))
+	return store
+}
+
+// defaultVaultPath はアプリのストレージ内の既定の保存データファイルのパスを返す
+func defaultVaultPath(fyneApp fyne.App) string {
+	return filepath.Join(fyneApp.Storage().RootURI().Path(), totpstore.VaultFileName)
+}
+
+// applyTimeOffset は保存された時刻オフセットをTOTPストアのClockに反映する
+// 設定できる範囲を超える値が保存されている場合は補正しない
+func (a *App) applyTimeOffset() {
+	seconds := a.preferences.GetTimeOffset()
+	if seconds < -maxTimeOffset || seconds > maxTimeOffset {
+		seconds = 0
+	}
+	offset := time.Duration(seconds) * time.Second
+	a.totpStore.SetClock(totp.WithOffset(totp.SystemClock, offset))
+}
+
+// purgeExpiredTrash はゴミ箱の保持期間を過ぎたエントリを完全に削除して保存する
+func (a *App) purgeExpiredTrash() {
+	retention := time.Duration(a.preferences.GetTrashRetentionDays()) * 24 * time.Hour
+	if a.totpStore.PurgeExpired(retention) > 0 {
+		_ = a.totpStore.Save()
+	}
 }
 
 // Run はアプリケーションを起動する
@@ -77,18 +131,27 @@ func (a *App) Run() {
 	savedLanguage := a.preferences.GetLanguage()
 	_ = assets.InitI18nWithLocale(savedLanguage)
 
-	// TOTPデータを読み込み（エラーが発生しても空のストアとして続行）
+	// TOTPデータを読み込み（読み込めない場合は復旧モードの空のストアとして続行し、起動後に復旧方法を案内する）
 	_ = a.totpStore.Load()
 
+	// ゴミ箱の保持期間を過ぎたエントリを完全に削除
+	a.purgeExpiredTrash()
+
 	a.mainWindow = a.fyneApp.NewWindow(lang.L("app.title"))
 	a.mainWindow.Resize(fyne.NewSize(650, 450))
 
 	content := a.createUI()
 	a.mainWindow.SetContent(content)
 
-	// アプリ終了時にクリップボードをクリア
+	// 保存データを読み込めなかった場合は復旧ダイアログを表示
+	if failure := a.totpStore.LoadFailure(); failure != nil {
+		a.showRecoveryDialog(failure)
+	}
+
+	// アプリ終了時にクリップボードをクリアし、保存していない使用履歴を保存
 	a.mainWindow.SetCloseIntercept(func() {
 		a.clipboard.Clear()
+		_ = a.totpStore.FlushUsage()
 		a.mainWindow.Close()
 	})
 
@@ -198,6 +261,6 @@ func (a *App) updateToolbarState() {
 // handleAddButton は追加ボタンの処理を行う
 func (a *App) handleAddButton() {
 	if a.totpListView != nil {
-		a.totpListView.scanQRCode()
+		a.totpListView.showAddMenu(a.addButton)
 	}
 }
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+	"github.com/nktmys/winticator/src/usecase/totpstore"
+)
+
+// showRecoveryDialog は保存データを読み込めなかったことを通知し、復旧方法を選択させる
+func (a *App) showRecoveryDialog(failure *totpstore.LoadFailure) {
+	message := lang.L("recovery.message", M{"Reason": failure.Err.Error()})
+	if failure.QuarantinePath != "" {
+		message += "\n\n" + lang.L("recovery.quarantined", M{"Path": failure.QuarantinePath})
+	} else {
+		message += "\n\n" + lang.L("recovery.locked")
+	}
+	messageLabel := widget.NewLabel(message)
+	messageLabel.Wrapping = fyne.TextWrapWord
+
+	recoveryDialog := dialog.NewCustomWithoutButtons(lang.L("recovery.title"), messageLabel, a.mainWindow)
+	recoveryDialog.SetButtons([]fyne.CanvasObject{
+		widget.NewButton(lang.L("recovery.continue"), func() {
+			recoveryDialog.Hide()
+		}),
+		&widget.Button{
+			Text:       lang.L("recovery.backup"),
+			Importance: widget.HighImportance,
+			OnTapped: func() {
+				recoveryDialog.Hide()
+				a.restoreFromBackup(failure)
+			},
+		},
+	})
+	recoveryDialog.Resize(fyne.NewSize(500, 300))
+	recoveryDialog.Show()
+}
+
+// restoreFromBackup はバックアップファイルからエントリを復元する（中止した場合は復旧ダイアログに戻る）
+// 読み込めなかった保存データを隔離できていない場合は、上書きしてよいか確認してから復元し、
+// 復元したエントリを保存できた場合のみ復旧モードを解除する
+func (a *App) restoreFromBackup(failure *totpstore.LoadFailure) {
+	if a.settingsView == nil {
+		return
+	}
+	onFinished := func(imported bool) {
+		if !imported {
+			a.showRecoveryDialog(failure)
+		}
+	}
+	if failure.QuarantinePath != "" {
+		a.settingsView.importBackup(&backupImport{onFinished: onFinished})
+		return
+	}
+
+	dialog.ShowConfirm(
+		lang.L("recovery.title"),
+		lang.L("recovery.backup.overwrite"),
+		func(confirmed bool) {
+			if !confirmed {
+				a.showRecoveryDialog(failure)
+				return
+			}
+			a.settingsView.importBackup(&backupImport{
+				abandonRecovery: true,
+				onFinished:      onFinished,
+			})
+		},
+		a.mainWindow,
+	)
+}
@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"errors"
 	"slices"
+	"strconv"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -20,6 +22,7 @@ func (a *App) createSettingsTab() fyne.CanvasObject {
 		preferences: a.preferences,
 		settings:    a.fyneApp.Settings(),
 	}
+	a.settingsView = tab
 
 	// テーマ設定
 	themeLabel := widget.NewLabel(lang.L("settings.theme"))
@@ -73,11 +76,44 @@ func (a *App) createSettingsTab() fyne.CanvasObject {
 		tab.languageSelect.SetSelected(tab.locales[0].Name) // システム設定をデフォルト
 	}
 
+	// 時刻補正設定
+	timeOffsetLabel := widget.NewLabel(lang.L("settings.timeoffset"))
+	tab.timeOffsetEntry = widget.NewEntry()
+	tab.timeOffsetEntry.SetPlaceHolder("0")
+	tab.timeOffsetEntry.Validator = validateTimeOffset
+	tab.timeOffsetEntry.SetText(strconv.Itoa(a.preferences.GetTimeOffset()))
+	tab.timeOffsetEntry.OnChanged = tab.handleTimeOffsetChanged
+	timeOffsetHint := widget.NewLabel(lang.L("settings.timeoffset.hint"))
+	timeOffsetHint.Wrapping = fyne.TextWrapWord
+
+	// 次のコードのコピー設定
+	copyNextLabel := widget.NewLabel(lang.L("settings.copynext"))
+	tab.copyNextEntry = widget.NewEntry()
+	tab.copyNextEntry.SetPlaceHolder("0")
+	tab.copyNextEntry.Validator = validateCopyNextThreshold
+	tab.copyNextEntry.SetText(strconv.Itoa(a.preferences.GetCopyNextThreshold()))
+	tab.copyNextEntry.OnChanged = tab.handleCopyNextChanged
+	copyNextHint := widget.NewLabel(lang.L("settings.copynext.hint"))
+	copyNextHint.Wrapping = fyne.TextWrapWord
+
 	// データ管理セクション
 	dataLabel := widget.NewLabel(lang.L("settings.data"))
 	exportButton := widget.NewButton(lang.L("settings.export"), tab.handleExport)
 	importButton := widget.NewButton(lang.L("settings.import"), tab.handleImport)
-	dataButtons := container.NewHBox(exportButton, importButton)
+	restoreButton := widget.NewButton(lang.L("settings.restore"), tab.showRestoreDialog)
+	trashButton := widget.NewButton(lang.L("settings.trash"), tab.showTrashDialog)
+	migrationButton := widget.NewButton(lang.L("settings.exportMigration"), tab.handleMigrationExport)
+	dataButtons := container.NewVBox(
+		container.NewHBox(exportButton, importButton, restoreButton, trashButton),
+		container.NewHBox(migrationButton),
+	)
+
+	// 保存データファイルの場所
+	vaultLabel := widget.NewLabel(lang.L("settings.vault"))
+	tab.vaultPathLabel = widget.NewLabel("")
+	tab.vaultPathLabel.Wrapping = fyne.TextWrapBreak
+	tab.updateVaultPathLabel()
+	vaultButton := widget.NewButton(lang.L("settings.vault.change"), tab.handleChangeVaultLocation)
 
 	content := container.NewVBox(
 		themeLabel,
@@ -87,8 +123,20 @@ func (a *App) createSettingsTab() fyne.CanvasObject {
 		tab.languageSelect,
 		tab.restartLabel,
 		widget.NewSeparator(),
+		timeOffsetLabel,
+		tab.timeOffsetEntry,
+		timeOffsetHint,
+		widget.NewSeparator(),
+		copyNextLabel,
+		tab.copyNextEntry,
+		copyNextHint,
+		widget.NewSeparator(),
 		dataLabel,
 		dataButtons,
+		widget.NewSeparator(),
+		vaultLabel,
+		tab.vaultPathLabel,
+		container.NewHBox(vaultButton),
 	)
 
 	return container.NewPadded(content)
@@ -103,6 +151,10 @@ type settingsTab struct {
 	languageSelect *widget.Select
 	restartLabel   *widget.Label
 	locales        []assets.Locale
+
+	timeOffsetEntry *widget.Entry
+	copyNextEntry   *widget.Entry
+	vaultPathLabel  *widget.Label
 }
 
 // handleThemeRadio はテーマ変更時の処理を行う
@@ -143,3 +195,64 @@ func (t *settingsTab) handleLanguageSelect(selected string) {
 	// 再起動が必要な旨を表示
 	t.restartLabel.Show()
 }
+
+// maxTimeOffset は設定できる時刻オフセットの最大の絶対値（秒）
+// 時計のずれの補正に十分な範囲に制限し、秒数からtime.Durationへの変換が桁あふれしないようにする
+const maxTimeOffset = 24 * 60 * 60
+
+// validateTimeOffset は時刻オフセットの入力値（±1日以内の秒単位の整数）を検証する
+func validateTimeOffset(text string) error {
+	if text == "" {
+		return nil
+	}
+	if offset, err := strconv.Atoi(text); err != nil || offset < -maxTimeOffset || offset > maxTimeOffset {
+		return errors.New(lang.L("settings.timeoffset.invalid"))
+	}
+	return nil
+}
+
+// handleTimeOffsetChanged は時刻オフセット変更時の処理を行う
+func (t *settingsTab) handleTimeOffsetChanged(text string) {
+	if validateTimeOffset(text) != nil {
+		return
+	}
+
+	// 空欄は補正なしとして扱う
+	offset, _ := strconv.Atoi(text)
+
+	// 値に変更がなければ何もしない
+	if t.preferences.GetTimeOffset() == offset {
+		return
+	}
+
+	t.preferences.SetTimeOffset(offset)
+	t.app.applyTimeOffset()
+}
+
+// validateCopyNextThreshold は次のコードをコピーする残り秒数（0以上の整数）を検証する
+func validateCopyNextThreshold(text string) error {
+	if text == "" {
+		return nil
+	}
+	if seconds, err := strconv.Atoi(text); err != nil || seconds < 0 {
+		return errors.New(lang.L("settings.copynext.invalid"))
+	}
+	return nil
+}
+
+// handleCopyNextChanged は次のコードをコピーする残り秒数の変更時の処理を行う
+func (t *settingsTab) handleCopyNextChanged(text string) {
+	if validateCopyNextThreshold(text) != nil {
+		return
+	}
+
+	// 空欄は無効（常に現在のコードをコピー）として扱う
+	seconds, _ := strconv.Atoi(text)
+
+	// 値に変更がなければ何もしない
+	if t.preferences.GetCopyNextThreshold() == seconds {
+		return
+	}
+
+	t.preferences.SetCopyNextThreshold(seconds)
+}
@@ -2,7 +2,10 @@ package ui
 
 import (
 	"encoding/base64"
-	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
@@ -51,8 +54,8 @@ func (t *settingsTab) doExport(password string) {
 		return
 	}
 
-	// JSONにシリアライズ
-	data, err := json.Marshal(entries)
+	// 現在のスキーマでJSONにシリアライズ
+	data, err := totpstore.EncodeVault(entries)
 	if err != nil {
 		dialog.ShowError(err, t.app.mainWindow)
 		return
@@ -97,14 +100,33 @@ func (t *settingsTab) doExport(password string) {
 	saveDialog.Show()
 }
 
+// backupImport はバックアップファイルからのインポートの設定
+type backupImport struct {
+	abandonRecovery bool                // インポートを保存できた場合に復旧モードを解除する
+	onFinished      func(imported bool) // インポートの完了・中止時に呼び出す（nilの場合は何もしない）
+}
+
+// finish はインポートの完了・中止を通知する
+func (b *backupImport) finish(imported bool) {
+	if b.onFinished != nil {
+		b.onFinished(imported)
+	}
+}
+
 // handleImport はインポート処理を行う
 func (t *settingsTab) handleImport() {
+	t.importBackup(&backupImport{})
+}
+
+// importBackup はバックアップファイルを選択してインポートする
+func (t *settingsTab) importBackup(req *backupImport) {
 	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
 		if err != nil {
-			dialog.ShowError(err, t.app.mainWindow)
+			t.showImportError(err, req)
 			return
 		}
 		if reader == nil {
+			req.finish(false)
 			return
 		}
 		defer reader.Close()
@@ -135,9 +157,10 @@ func (t *settingsTab) handleImport() {
 			},
 			func(confirmed bool) {
 				if !confirmed || passwordEntry.Text == "" {
+					req.finish(false)
 					return
 				}
-				t.doImport(data, passwordEntry.Text)
+				t.doImport(data, passwordEntry.Text, req)
 			},
 			t.app.mainWindow,
 		)
@@ -150,25 +173,31 @@ func (t *settingsTab) handleImport() {
 }
 
 // doImport は実際のインポート処理を行う
-func (t *settingsTab) doImport(data []byte, password string) {
+func (t *settingsTab) doImport(data []byte, password string, req *backupImport) {
 	// Base64デコード
 	decoded, err := base64.StdEncoding.DecodeString(string(data))
 	if err != nil {
-		dialog.ShowError(err, t.app.mainWindow)
+		t.showImportError(err, req)
 		return
 	}
 
 	// パスワードで復号
 	decrypted, err := crypto.Decrypt(password, decoded)
 	if err != nil {
-		dialog.ShowError(err, t.app.mainWindow)
+		t.showImportError(err, req)
 		return
 	}
 
-	// JSONをデシリアライズ
-	var entries []*totpstore.Entry
-	if err := json.Unmarshal(decrypted, &entries); err != nil {
-		dialog.ShowError(err, t.app.mainWindow)
+	// JSONをデシリアライズ（過去のスキーマのバックアップは現在のスキーマに変換する）
+	entries, err := totpstore.DecodeVault(decrypted)
+	if err != nil {
+		t.showImportError(err, req)
+		return
+	}
+
+	// 無効なパラメータやシークレットのエントリを含む場合は取り込まない
+	if err := totpstore.ValidateEntries(entries); err != nil {
+		t.showImportError(err, req)
 		return
 	}
 
@@ -179,42 +208,127 @@ func (t *settingsTab) doImport(data []byte, password string) {
 			lang.L("settings.import.merge"),
 			func(merge bool) {
 				if !merge {
-					// 既存データを削除
-					for _, e := range t.app.totpStore.GetAll() {
-						_ = t.app.totpStore.Delete(e.ID)
-					}
+					t.importEntries(entries, totpstore.ResolveKeepBoth, true, req)
+					return
 				}
-				t.importEntries(entries)
+				t.mergeEntries(entries, req)
 			},
 			t.app.mainWindow,
 		)
 	} else {
-		t.importEntries(entries)
+		t.mergeEntries(entries, req)
 	}
 }
 
-// importEntries はエントリをインポートする
-func (t *settingsTab) importEntries(entries []*totpstore.Entry) {
-	for _, entry := range entries {
-		if err := t.app.totpStore.Add(entry); err != nil {
-			dialog.ShowError(err, t.app.mainWindow)
-			return
-		}
-	}
-
-	if err := t.app.totpStore.Save(); err != nil {
-		dialog.ShowError(err, t.app.mainWindow)
+// mergeEntries は登録済みのエントリと重複する場合は処理方法を選択させてからインポートする
+func (t *settingsTab) mergeEntries(entries []*totpstore.Entry, req *backupImport) {
+	duplicates := t.app.totpStore.CountDuplicates(entries)
+	if duplicates == 0 {
+		t.importEntries(entries, totpstore.ResolveKeepBoth, false, req)
 		return
 	}
+	showDuplicateResolutionDialog(
+		t.app.mainWindow,
+		lang.L("totp.duplicate.batch", M{"Count": strconv.Itoa(duplicates)}),
+		func(resolution totpstore.DuplicateResolution) {
+			t.importEntries(entries, resolution, false, req)
+		},
+		func() {
+			req.finish(false)
+		},
+	)
+}
 
-	// TOTPリストを更新
-	if t.app.totpListView != nil {
-		t.app.totpListView.refreshEntries()
+// importEntries はエントリをまとめてインポートする（失敗した場合は何も変更しない）
+// 重複する場合はresolutionに従って処理し、replaceの場合は既存のエントリをゴミ箱に移動してからインポートする
+// インポート前の保存データに戻せるよう、変更する前にスナップショットを作成する
+func (t *settingsTab) importEntries(entries []*totpstore.Entry, resolution totpstore.DuplicateResolution, replace bool, req *backupImport) {
+	err := t.app.totpStore.Batch(func(tx *totpstore.Tx) error {
+		if err := tx.Snapshot(); err != nil {
+			return err
+		}
+		if req.abandonRecovery {
+			tx.AbandonRecovery()
+		}
+		if replace {
+			tx.DeleteAll()
+		}
+		for _, entry := range entries {
+			tx.AddResolved(entry, resolution)
+		}
+		return nil
+	})
+	if err != nil {
+		t.showImportError(err, req)
+		return
 	}
 
-	dialog.ShowInformation(
+	successDialog := dialog.NewInformation(
 		lang.L("settings.import.title"),
 		lang.L("settings.import.success"),
 		t.app.mainWindow,
 	)
+	successDialog.SetOnClosed(func() {
+		req.finish(true)
+	})
+	successDialog.Show()
+}
+
+// showImportError はインポートのエラーを表示し、閉じた後にインポートの中止を通知する
+func (t *settingsTab) showImportError(err error, req *backupImport) {
+	errorDialog := dialog.NewError(err, t.app.mainWindow)
+	errorDialog.SetOnClosed(func() {
+		req.finish(false)
+	})
+	errorDialog.Show()
+}
+
+// updateVaultPathLabel は保存データの保存先の表示を更新する
+func (t *settingsTab) updateVaultPathLabel() {
+	if file, ok := t.app.totpStore.Backend().(*totpstore.FileBackend); ok {
+		t.vaultPathLabel.SetText(file.Path())
+		return
+	}
+	t.vaultPathLabel.SetText(lang.L("settings.vault.preferences"))
+}
+
+// handleChangeVaultLocation は選択したフォルダに保存データファイルを移動する
+// 選択したフォルダに保存データファイルが既にある場合は上書きしない
+func (t *settingsTab) handleChangeVaultLocation() {
+	folderDialog := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, t.app.mainWindow)
+			return
+		}
+		if dir == nil {
+			return
+		}
+
+		path := filepath.Join(dir.Path(), totpstore.VaultFileName)
+		if file, ok := t.app.totpStore.Backend().(*totpstore.FileBackend); ok && file.Path() == path {
+			return
+		}
+		if _, err := os.Stat(path); err == nil {
+			dialog.ShowError(errors.New(lang.L("settings.vault.exists")), t.app.mainWindow)
+			return
+		}
+
+		cleanupErr, err := t.app.totpStore.SwitchBackend(totpstore.NewFileBackend(path))
+		if err != nil {
+			dialog.ShowError(err, t.app.mainWindow)
+			return
+		}
+		t.preferences.SetVaultPath(path)
+		t.updateVaultPathLabel()
+
+		// 切り替え後の後片付けに失敗しても保存先は変更済みのため、警告のみ表示する
+		if cleanupErr != nil {
+			dialog.ShowInformation(
+				lang.L("settings.vault"),
+				lang.L("settings.vault.cleanupFailed", M{"Reason": cleanupErr.Error()}),
+				t.app.mainWindow,
+			)
+		}
+	}, t.app.mainWindow)
+	folderDialog.Show()
 }
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"errors"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/nktmys/winticator/src/usecase/qrscanner"
+	"github.com/nktmys/winticator/src/usecase/totpstore"
+)
+
+// handleMigrationExport は確認後にエントリをGoogle Authenticatorで読み取れるQRコードとして表示する
+func (t *settingsTab) handleMigrationExport() {
+	entries := t.app.totpStore.GetAll()
+	if len(entries) == 0 {
+		dialog.ShowInformation(
+			lang.L("settings.exportMigration.title"),
+			lang.L("totp.empty"),
+			t.app.mainWindow,
+		)
+		return
+	}
+
+	dialog.ShowConfirm(
+		lang.L("settings.exportMigration.title"),
+		lang.L("settings.exportMigration.confirm"),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			t.showMigrationExport(entries)
+		},
+		t.app.mainWindow,
+	)
+}
+
+// showMigrationExport はエントリをotpauth-migration URIのQRコードに変換し、1枚ずつ切り替えて表示する
+// Google Authenticatorで表せないエントリは含めず、理由を表示する
+func (t *settingsTab) showMigrationExport(entries []*totpstore.Entry) {
+	uris, warnings, err := totpstore.ExportOTPAuthMigrationURIs(entries, totpstore.MigrationBatchSize)
+	if err != nil {
+		message := err.Error()
+		if errors.Is(err, totpstore.ErrNoMigrationEntries) {
+			message = lang.L("settings.exportMigration.none")
+		}
+		if len(warnings) > 0 {
+			message += "\n\n" + formatExportWarnings(warnings)
+		}
+		dialog.ShowError(errors.New(message), t.app.mainWindow)
+		return
+	}
+
+	img := canvas.NewImageFromResource(nil)
+	img.FillMode = canvas.ImageFillContain
+	img.ScaleMode = canvas.ImageScalePixels // QRコードを拡大しても読み取れるようにぼかさない
+	img.SetMinSize(fyne.NewSize(280, 280))
+	pageLabel := widget.NewLabel("")
+	pageLabel.Alignment = fyne.TextAlignCenter
+
+	var prevButton, nextButton *widget.Button
+	index := 0
+	show := func(i int) {
+		qr, err := qrscanner.GenerateQRCodeImage(uris[i])
+		if err != nil {
+			dialog.ShowError(err, t.app.mainWindow)
+			return
+		}
+		index = i
+		img.Image = qr
+		img.Refresh()
+		pageLabel.SetText(lang.L("settings.exportMigration.page", M{
+			"Index": strconv.Itoa(i + 1),
+			"Count": strconv.Itoa(len(uris)),
+		}))
+		if i > 0 {
+			prevButton.Enable()
+		} else {
+			prevButton.Disable()
+		}
+		if i < len(uris)-1 {
+			nextButton.Enable()
+		} else {
+			nextButton.Disable()
+		}
+	}
+	prevButton = widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		show(index - 1)
+	})
+	nextButton = widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		show(index + 1)
+	})
+
+	content := container.NewVBox(
+		img,
+		container.NewBorder(nil, nil, prevButton, nextButton, pageLabel),
+	)
+	if len(warnings) > 0 {
+		warningLabel := widget.NewLabel(formatExportWarnings(warnings))
+		warningLabel.Wrapping = fyne.TextWrapWord
+		content.Add(warningLabel)
+	}
+	show(0)
+
+	exportDialog := dialog.NewCustom(
+		lang.L("settings.exportMigration.title"),
+		lang.L("dialog.close"),
+		content,
+		t.app.mainWindow,
+	)
+	exportDialog.Resize(fyne.NewSize(420, 0))
+	exportDialog.Show()
+}
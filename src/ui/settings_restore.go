@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+	"github.com/nktmys/winticator/src/usecase/totpstore"
+)
+
+// showRestoreDialog は保存データのスナップショットから以前のバージョンを復元するダイアログを表示する
+func (t *settingsTab) showRestoreDialog() {
+	snapshots, err := t.app.totpStore.Snapshots()
+	if errors.Is(err, totpstore.ErrSnapshotsUnsupported) || (err == nil && len(snapshots) == 0) {
+		dialog.ShowInformation(
+			lang.L("settings.restore.title"),
+			lang.L("settings.restore.empty"),
+			t.app.mainWindow,
+		)
+		return
+	}
+	if err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+		return
+	}
+
+	var restoreDialog dialog.Dialog
+	list := widget.NewList(
+		func() int {
+			return len(snapshots)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			label, _ := item.(*widget.Label)
+			label.SetText(snapshots[id].CreatedAt.Local().Format(time.DateTime))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		list.UnselectAll()
+		t.confirmRestore(snapshots[id], func() {
+			restoreDialog.Hide()
+		})
+	}
+
+	hint := widget.NewLabel(lang.L("settings.restore.hint"))
+	hint.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(hint, nil, nil, nil, list)
+
+	restoreDialog = dialog.NewCustom(
+		lang.L("settings.restore.title"),
+		lang.L("dialog.close"),
+		content,
+		t.app.mainWindow,
+	)
+	restoreDialog.Resize(fyne.NewSize(420, 400))
+	restoreDialog.Show()
+}
+
+// confirmRestore はスナップショットの内容を確認してから復元する（復元後にonRestoredを呼び出す）
+func (t *settingsTab) confirmRestore(snapshot totpstore.VaultSnapshot, onRestored func()) {
+	entries, err := t.app.totpStore.SnapshotEntries(snapshot.ID)
+	if err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+		return
+	}
+
+	dialog.ShowConfirm(
+		lang.L("settings.restore.title"),
+		lang.L("settings.restore.confirm", M{
+			"Time":  snapshot.CreatedAt.Local().Format(time.DateTime),
+			"Count": strconv.Itoa(len(entries)),
+		}),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := t.app.totpStore.RestoreSnapshot(snapshot.ID); err != nil {
+				dialog.ShowError(err, t.app.mainWindow)
+				return
+			}
+
+			onRestored()
+			dialog.ShowInformation(
+				lang.L("settings.restore.title"),
+				lang.L("settings.restore.success"),
+				t.app.mainWindow,
+			)
+		},
+		t.app.mainWindow,
+	)
+}
@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/nktmys/winticator/src/usecase/totpstore"
+)
+
+// trashRetentionDays はゴミ箱の保持期間として選択できる日数（0は自動的に削除しない）
+var trashRetentionDays = []int{7, 30, 90, 365, 0}
+
+// showTrashDialog はゴミ箱のエントリを表示し、復元・完全に削除するダイアログを表示する
+func (t *settingsTab) showTrashDialog() {
+	var entries []*totpstore.Entry
+	emptyLabel := widget.NewLabel(lang.L("settings.trash.empty"))
+	emptyLabel.Alignment = fyne.TextAlignCenter
+
+	var list *widget.List
+	var emptyButton *widget.Button
+	refresh := func() {
+		entries = t.app.totpStore.Trash()
+		list.Refresh()
+		if len(entries) == 0 {
+			list.Hide()
+			emptyLabel.Show()
+			emptyButton.Disable()
+		} else {
+			emptyLabel.Hide()
+			list.Show()
+			emptyButton.Enable()
+		}
+	}
+
+	list = widget.NewList(
+		func() int {
+			return len(entries)
+		},
+		func() fyne.CanvasObject {
+			nameLabel := widget.NewLabel("")
+			nameLabel.Truncation = fyne.TextTruncateEllipsis
+			deletedLabel := widget.NewLabel("")
+			deletedLabel.Importance = widget.LowImportance
+			restoreButton := widget.NewButtonWithIcon("", theme.ContentUndoIcon(), nil)
+			purgeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			return container.NewBorder(nil, nil, nil,
+				container.NewHBox(restoreButton, purgeButton),
+				container.NewVBox(nameLabel, deletedLabel),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			if id >= len(entries) {
+				return
+			}
+			entry := entries[id]
+			row, _ := item.(*fyne.Container)
+			labels, _ := row.Objects[0].(*fyne.Container)
+			buttons, _ := row.Objects[1].(*fyne.Container)
+
+			nameLabel, _ := labels.Objects[0].(*widget.Label)
+			nameLabel.SetText(entry.DisplayName())
+			deletedLabel, _ := labels.Objects[1].(*widget.Label)
+			deletedLabel.SetText(lang.L("settings.trash.deletedAt", M{
+				"Time": entry.DeletedAt.Local().Format(time.DateTime),
+			}))
+
+			restoreButton, _ := buttons.Objects[0].(*widget.Button)
+			restoreButton.OnTapped = func() {
+				t.restoreFromTrash(entry, refresh)
+			}
+			purgeButton, _ := buttons.Objects[1].(*widget.Button)
+			purgeButton.OnTapped = func() {
+				t.confirmPurge(entry, refresh)
+			}
+		},
+	)
+
+	emptyButton = widget.NewButtonWithIcon(lang.L("settings.trash.emptyTrash"), theme.DeleteIcon(), func() {
+		t.confirmEmptyTrash(len(entries), refresh)
+	})
+	emptyButton.Importance = widget.DangerImportance
+
+	// 保持期間の選択
+	retentionSelect := widget.NewSelect(trashRetentionLabels(), nil)
+	retentionSelect.SetSelected(trashRetentionLabel(t.preferences.GetTrashRetentionDays()))
+	retentionSelect.OnChanged = func(selected string) {
+		index := slices.Index(retentionSelect.Options, selected)
+		if index < 0 || index >= len(trashRetentionDays) {
+			return
+		}
+		t.handleTrashRetentionChanged(trashRetentionDays[index])
+		refresh()
+	}
+
+	hint := widget.NewLabel(lang.L("settings.trash.hint"))
+	hint.Wrapping = fyne.TextWrapWord
+	header := container.NewVBox(
+		hint,
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("settings.trash.retention")), nil, retentionSelect),
+	)
+	content := container.NewBorder(
+		header,
+		container.NewHBox(emptyButton),
+		nil, nil,
+		container.NewStack(list, emptyLabel),
+	)
+	refresh()
+
+	trashDialog := dialog.NewCustom(
+		lang.L("settings.trash.title"),
+		lang.L("dialog.close"),
+		content,
+		t.app.mainWindow,
+	)
+	trashDialog.Resize(fyne.NewSize(480, 460))
+	trashDialog.Show()
+}
+
+// restoreFromTrash はエントリをゴミ箱から戻して保存する（保存後にonChangedを呼び出す）
+func (t *settingsTab) restoreFromTrash(entry *totpstore.Entry, onChanged func()) {
+	if err := t.app.totpStore.RestoreFromTrash(entry.ID); err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+		return
+	}
+	if err := t.app.totpStore.Save(); err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+	}
+	onChanged()
+}
+
+// confirmPurge は確認後にエントリをゴミ箱から完全に削除する（削除後にonChangedを呼び出す）
+func (t *settingsTab) confirmPurge(entry *totpstore.Entry, onChanged func()) {
+	dialog.ShowConfirm(
+		lang.L("settings.trash.purge"),
+		lang.L("settings.trash.purge.confirm", M{"displayName": entry.DisplayName()}),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := t.app.totpStore.Purge(entry.ID); err != nil {
+				dialog.ShowError(err, t.app.mainWindow)
+				return
+			}
+			if err := t.app.totpStore.Save(); err != nil {
+				dialog.ShowError(err, t.app.mainWindow)
+			}
+			onChanged()
+		},
+		t.app.mainWindow,
+	)
+}
+
+// confirmEmptyTrash は確認後にゴミ箱のエントリをすべて完全に削除する（削除後にonChangedを呼び出す）
+func (t *settingsTab) confirmEmptyTrash(count int, onChanged func()) {
+	dialog.ShowConfirm(
+		lang.L("settings.trash.emptyTrash"),
+		lang.L("settings.trash.emptyTrash.confirm", M{"Count": strconv.Itoa(count)}),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			t.app.totpStore.EmptyTrash()
+			if err := t.app.totpStore.Save(); err != nil {
+				dialog.ShowError(err, t.app.mainWindow)
+			}
+			onChanged()
+		},
+		t.app.mainWindow,
+	)
+}
+
+// handleTrashRetentionChanged はゴミ箱の保持期間を保存し、保持期間を過ぎたエントリを完全に削除する
+func (t *settingsTab) handleTrashRetentionChanged(days int) {
+	if t.preferences.GetTrashRetentionDays() == days {
+		return
+	}
+	t.preferences.SetTrashRetentionDays(days)
+	t.app.purgeExpiredTrash()
+}
+
+// trashRetentionLabels は選択できるゴミ箱の保持期間の表示名を返す
+func trashRetentionLabels() []string {
+	labels := make([]string, len(trashRetentionDays))
+	for i, days := range trashRetentionDays {
+		labels[i] = trashRetentionLabel(days)
+	}
+	return labels
+}
+
+// trashRetentionLabel はゴミ箱の保持期間の表示名を返す
+func trashRetentionLabel(days int) string {
+	if days <= 0 {
+		return lang.L("settings.trash.retention.never")
+	}
+	return lang.L("settings.trash.retention.days", M{"Days": strconv.Itoa(days)})
+}
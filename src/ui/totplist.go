@@ -6,6 +6,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -13,6 +14,7 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/widget"
+	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/nktmys/winticator/src/ui/custom/components"
 	"github.com/nktmys/winticator/src/usecase/clipboard"
 	"github.com/nktmys/winticator/src/usecase/qrscanner"
@@ -22,15 +24,19 @@ import (
 // createTOTPListTab はTOTPリスト画面を作成する
 func (a *App) createTOTPListTab() fyne.CanvasObject {
 	view := &totpListTab{
-		app:       a,
-		store:     a.totpStore,
-		clipboard: a.clipboard,
-		entries:   make([]*totpstore.Entry, 0),
-		stopChan:  make(chan bool),
+		app:           a,
+		store:         a.totpStore,
+		clipboard:     a.clipboard,
+		entries:       make([]*totpstore.Entry, 0),
+		hotpCodes:     make(map[string]string),
+		iconResources: make(map[string]fyne.Resource),
+		stopChan:      make(chan bool),
 	}
 
-	// ストアからエントリを読み込み
+	// ストアからエントリを読み込み、保存された並び順でソート
+	view.sortMode = totpstore.ParseSortMode(a.preferences.GetSortMode())
 	view.entries = view.store.GetAll()
+	totpstore.SortEntries(view.entries, view.sortMode)
 	view.filteredEntries = slices.Clone(view.entries)
 
 	// 検索エントリを作成
@@ -39,6 +45,27 @@ func (a *App) createTOTPListTab() fyne.CanvasObject {
 		view.filterEntries(query)
 	}
 
+	// タグフィルターを作成
+	view.tagSelect = widget.NewSelect(nil, func(selected string) {
+		view.tagFilter = ""
+		if selected != lang.L("totp.tags.all") {
+			view.tagFilter = selected
+		}
+		view.filterEntries(view.searchEntry.Text)
+	})
+	view.updateTagOptions()
+
+	// 並び順の選択を作成
+	view.sortSelect = widget.NewSelect(sortModeLabels(), func(selected string) {
+		for _, mode := range totpstore.SortModes {
+			if sortModeLabel(mode) == selected {
+				view.setSortMode(mode)
+				return
+			}
+		}
+	})
+	view.sortSelect.SetSelected(sortModeLabel(view.sortMode))
+
 	// リストを作成
 	view.list = widget.NewList(
 		func() int {
@@ -68,12 +95,16 @@ func (a *App) createTOTPListTab() fyne.CanvasObject {
 
 	// コンテナを作成
 	listStack := container.NewStack(view.list, view.emptyLabel)
-	view.container = container.NewBorder(view.searchEntry, nil, nil, nil, listStack)
+	header := container.NewBorder(nil, nil, nil, container.NewHBox(view.sortSelect, view.tagSelect), view.searchEntry)
+	view.container = container.NewBorder(header, nil, nil, nil, listStack)
 	view.updateEmptyState()
 
 	// 定期更新を開始
 	view.startRefresh()
 
+	// ストアの変更に合わせてリストを更新
+	view.store.Subscribe(view.handleStoreEvent)
+
 	// Appに参照を保持
 	a.totpListView = view
 
@@ -88,11 +119,18 @@ type totpListTab struct {
 	list            *widget.List
 	entries         []*totpstore.Entry
 	filteredEntries []*totpstore.Entry
+	hotpCodes       map[string]string        // エントリIDごとに最後に生成したHOTPコード
+	iconResources   map[string]fyne.Resource // エントリIDごとのアイコンのリソース
 	searchEntry     *components.SearchEntry
+	tagSelect       *widget.Select
+	tagFilter       string // 絞り込み中のタグ（空の場合はすべて）
+	sortSelect      *widget.Select
+	sortMode        totpstore.SortMode
 	emptyLabel      *widget.Label
 	ticker          *time.Ticker
 	stopChan        chan bool
 	container       *fyne.Container
+	refreshQueued   atomic.Bool // ストアの変更によるリストの更新を予約済みかどうか
 }
 
 // updateEmptyState は空の状態表示を更新する
@@ -129,20 +167,28 @@ func (t *totpListTab) startRefresh() {
 	}()
 }
 
-// isSearching は検索中かどうかを返す
+// isSearching は検索中（検索クエリまたはタグで絞り込み中）かどうかを返す
 func (t *totpListTab) isSearching() bool {
-	return t.searchEntry.Text != ""
+	return t.searchEntry.Text != "" || t.tagFilter != ""
 }
 
-// filterEntries は検索クエリに基づいてエントリをフィルタリングする
+// filterEntries は検索クエリと選択中のタグに基づいてエントリをフィルタリングする
+// 検索クエリはサービス名・アカウント名・タグに部分一致するエントリを対象とする
 func (t *totpListTab) filterEntries(query string) {
+	// GetAllの順序（ピン留めしたエントリが先頭）を保ったままフィルタリングする
 	t.filteredEntries = slices.Clone(t.entries)
+	if t.tagFilter != "" {
+		t.filteredEntries = slices.DeleteFunc(t.filteredEntries, func(entry *totpstore.Entry) bool {
+			return !entry.HasTag(t.tagFilter)
+		})
+	}
 	if query != "" {
 		q := strings.ToLower(query)
 		t.filteredEntries = slices.DeleteFunc(t.filteredEntries, func(entry *totpstore.Entry) bool {
 			issuer := strings.ToLower(entry.Issuer)
 			account := strings.ToLower(entry.Account)
-			return !strings.Contains(issuer, q) && !strings.Contains(account, q)
+			tags := strings.ToLower(totpstore.FormatTags(entry.Tags))
+			return !strings.Contains(issuer, q) && !strings.Contains(account, q) && !strings.Contains(tags, q)
 		})
 	}
 
@@ -163,9 +209,78 @@ func (t *totpListTab) filterEntries(query string) {
 // refreshEntries はエントリリストを更新する
 func (t *totpListTab) refreshEntries() {
 	t.entries = t.store.GetAll()
+	totpstore.SortEntries(t.entries, t.sortMode)
+	t.updateTagOptions()
 	t.filterEntries(t.searchEntry.Text)
 }
 
+// handleStoreEvent はストアの変更イベントを受け取り、UIスレッドでリストを更新する
+// 一括追加などで続けて変更された場合は、まとめて1回だけ更新する
+func (t *totpListTab) handleStoreEvent(totpstore.Event) {
+	if !t.refreshQueued.CompareAndSwap(false, true) {
+		return
+	}
+	fyne.Do(func() {
+		t.refreshQueued.Store(false)
+		t.refreshEntries()
+	})
+}
+
+// setSortMode は並び順を変更して保存し、リストを並べ替える
+func (t *totpListTab) setSortMode(mode totpstore.SortMode) {
+	if t.sortMode == mode {
+		return
+	}
+	t.sortMode = mode
+	t.app.preferences.SetSortMode(string(mode))
+	t.refreshEntries()
+}
+
+// sortModeLabel は並び順の表示名を返す
+func sortModeLabel(mode totpstore.SortMode) string {
+	switch mode {
+	case totpstore.SortIssuer:
+		return lang.L("totp.sort.issuer")
+	case totpstore.SortMostUsed:
+		return lang.L("totp.sort.mostused")
+	case totpstore.SortRecent:
+		return lang.L("totp.sort.recent")
+	default:
+		return lang.L("totp.sort.manual")
+	}
+}
+
+// sortModeLabels は並び順の選択肢の表示名を返す
+func sortModeLabels() []string {
+	labels := make([]string, len(totpstore.SortModes))
+	for i, mode := range totpstore.SortModes {
+		labels[i] = sortModeLabel(mode)
+	}
+	return labels
+}
+
+// updateTagOptions はタグフィルターの選択肢をストアのタグで更新する
+// 選択中のタグがなくなった場合はすべてのエントリを表示する
+func (t *totpListTab) updateTagOptions() {
+	all := lang.L("totp.tags.all")
+	tags := t.store.Tags()
+	t.tagSelect.Options = append([]string{all}, tags...)
+	if !slices.Contains(tags, t.tagFilter) {
+		t.tagFilter = ""
+	}
+
+	// SetSelectedは変更時にOnChangedを呼び出すため、選択肢の更新中は一時的に外す
+	onChanged := t.tagSelect.OnChanged
+	t.tagSelect.OnChanged = nil
+	if t.tagFilter == "" {
+		t.tagSelect.SetSelected(all)
+	} else {
+		t.tagSelect.SetSelected(t.tagFilter)
+	}
+	t.tagSelect.OnChanged = onChanged
+	t.tagSelect.Refresh()
+}
+
 // scanQRCode はQRコードをスキャンしてエントリを追加する
 func (t *totpListTab) scanQRCode() {
 	go func() {
@@ -173,7 +288,7 @@ func (t *totpListTab) scanQRCode() {
 		time.Sleep(500 * time.Millisecond)
 
 		// スキャン実行
-		results, err := qrscanner.CaptureAndScan()
+		results, warnings, err := qrscanner.CaptureAndScan()
 
 		// UIスレッドでウィンドウ復帰と結果処理
 		fyne.Do(func() {
@@ -192,6 +307,9 @@ func (t *totpListTab) scanQRCode() {
 				default:
 					errMsg = fmt.Sprintf("%s: %v", lang.L("totp.scan.error"), err)
 				}
+				if len(warnings) > 0 {
+					errMsg += "\n\n" + formatImportWarnings(warnings)
+				}
 				dialog.ShowError(errors.New(errMsg), t.app.mainWindow)
 				return
 			}
@@ -201,11 +319,7 @@ func (t *totpListTab) scanQRCode() {
 				return
 			}
 
-			if len(results) == 1 {
-				t.showAddConfirmDialog(results[0].Entry)
-			} else {
-				t.showBatchAddConfirmDialog(results)
-			}
+			t.showScanResults(results, warnings)
 		})
 	}()
 
@@ -213,49 +327,102 @@ func (t *totpListTab) scanQRCode() {
 	t.app.mainWindow.Hide()
 }
 
+// showScanResults はQRコードや貼り付けたURIから読み取ったエントリの追加確認ダイアログを表示する
+// 警告がある場合は1件でも一括追加ダイアログで警告を表示する
+func (t *totpListTab) showScanResults(results []qrscanner.ScanResult, warnings []totpstore.ImportWarning) {
+	if len(results) == 1 && len(warnings) == 0 {
+		t.showAddConfirmDialog(results[0].Entry)
+	} else {
+		t.showBatchAddConfirmDialog(results, warnings)
+	}
+}
+
 // showAddConfirmDialog は追加確認ダイアログを表示する
+// 登録済みのエントリと重複する場合は先に処理方法を選択させる
 func (t *totpListTab) showAddConfirmDialog(entry *totpstore.Entry) {
+	t.resolveDuplicate(entry, func(resolution totpstore.DuplicateResolution) {
+		t.showAddFormDialog(entry, resolution)
+	})
+}
+
+// resolveDuplicate はエントリが登録済みのエントリと重複する場合に処理方法を選択させる
+// 重複しない場合はそのまま、スキップ以外を選択した場合はその処理方法でonResolvedを呼び出す
+func (t *totpListTab) resolveDuplicate(entry *totpstore.Entry, onResolved func(totpstore.DuplicateResolution)) {
+	existing, ok := t.store.FindDuplicate(entry)
+	if !ok {
+		onResolved(totpstore.ResolveKeepBoth)
+		return
+	}
+	showDuplicateResolutionDialog(
+		t.app.mainWindow,
+		lang.L("totp.duplicate.message", M{"Name": existing.DisplayName()}),
+		func(resolution totpstore.DuplicateResolution) {
+			if resolution == totpstore.ResolveSkip {
+				return
+			}
+			onResolved(resolution)
+		},
+		nil,
+	)
+}
+
+// showAddFormDialog はエントリの追加フォームを表示する（重複する場合はresolutionに従って追加する）
+func (t *totpListTab) showAddFormDialog(entry *totpstore.Entry, resolution totpstore.DuplicateResolution) {
 	t.showEntryFormDialog(
 		entry,
 		lang.L("totp.add.title"),
 		lang.L("dialog.add"),
 		func(e *totpstore.Entry) error {
-			return t.store.Add(e)
+			return t.store.Batch(func(tx *totpstore.Tx) error {
+				tx.AddResolved(e, resolution)
+				return nil
+			})
 		},
 	)
 }
 
 // showBatchAddConfirmDialog は複数エントリの一括追加確認ダイアログを表示する
-func (t *totpListTab) showBatchAddConfirmDialog(results []qrscanner.ScanResult) {
+// 項目ごとの警告（アルゴリズムの推定や取り込まなかった項目）はエントリ名一覧の後に表示する
+// 登録済みのエントリと重複する項目がある場合は処理方法を選択させる
+func (t *totpListTab) showBatchAddConfirmDialog(results []qrscanner.ScanResult, warnings []totpstore.ImportWarning) {
 	// エントリ名一覧を作成
+	entries := make([]*totpstore.Entry, len(results))
 	names := make([]string, len(results))
 	for i, r := range results {
+		entries[i] = r.Entry
 		names[i] = "- " + r.Entry.DisplayName()
 	}
-	count := strconv.Itoa(len(results))
-	message := lang.L("totp.migration.confirm", M{"Count": count}) + "\n\n" + strings.Join(names, "\n")
+	message := lang.L("totp.migration.confirm", M{"Count": strconv.Itoa(len(results))}) + "\n\n" + strings.Join(names, "\n")
+	if len(warnings) > 0 {
+		message += "\n\n" + formatImportWarnings(warnings)
+	}
+
+	content := container.NewVBox(widget.NewLabel(message))
+	var resolutionGroup *widget.RadioGroup
+	if duplicates := t.store.CountDuplicates(entries); duplicates > 0 {
+		resolutionGroup = newDuplicateResolutionGroup()
+		content.Add(widget.NewLabel(lang.L("totp.duplicate.batch", M{"Count": strconv.Itoa(duplicates)})))
+		content.Add(resolutionGroup)
+	}
 
-	dialog.ShowConfirm(
+	dialog.ShowCustomConfirm(
 		lang.L("totp.migration.title"),
-		message,
+		lang.L("dialog.add"),
+		lang.L("dialog.cancel"),
+		content,
 		func(confirmed bool) {
 			if !confirmed {
 				return
 			}
-			for _, r := range results {
-				if err := t.store.Add(r.Entry); err != nil {
-					dialog.ShowError(err, t.app.mainWindow)
-					return
-				}
-			}
-			if err := t.store.Save(); err != nil {
+			// まとめて追加し、失敗した場合は1件も追加しない
+			added, err := t.store.AddMany(entries, selectedDuplicateResolution(resolutionGroup))
+			if err != nil {
 				dialog.ShowError(err, t.app.mainWindow)
 				return
 			}
-			t.refreshEntries()
 			dialog.ShowInformation(
 				lang.L("totp.migration.title"),
-				lang.L("totp.migration.success", M{"Count": count}),
+				lang.L("totp.migration.success", M{"Count": strconv.Itoa(added)}),
 				t.app.mainWindow,
 			)
 		},
@@ -263,7 +430,114 @@ func (t *totpListTab) showBatchAddConfirmDialog(results []qrscanner.ScanResult)
 	)
 }
 
+// showDuplicateResolutionDialog は重複したエントリの処理方法を選択するダイアログを表示する
+// キャンセルした場合はonResolvedを呼び出さず、onCancel（nilの場合は何もしない）を呼び出す
+func showDuplicateResolutionDialog(window fyne.Window, message string, onResolved func(totpstore.DuplicateResolution), onCancel func()) {
+	resolutionGroup := newDuplicateResolutionGroup()
+	dialog.ShowCustomConfirm(
+		lang.L("totp.duplicate.title"),
+		lang.L("dialog.ok"),
+		lang.L("dialog.cancel"),
+		container.NewVBox(widget.NewLabel(message), resolutionGroup),
+		func(confirmed bool) {
+			if confirmed {
+				onResolved(selectedDuplicateResolution(resolutionGroup))
+			} else if onCancel != nil {
+				onCancel()
+			}
+		},
+		window,
+	)
+}
+
+// duplicateResolutions は重複したエントリの処理方法の選択肢（表示順）
+var duplicateResolutions = []totpstore.DuplicateResolution{
+	totpstore.ResolveSkip,
+	totpstore.ResolveReplace,
+	totpstore.ResolveKeepBoth,
+}
+
+// duplicateResolutionLabel は重複したエントリの処理方法の表示名を返す
+func duplicateResolutionLabel(resolution totpstore.DuplicateResolution) string {
+	switch resolution {
+	case totpstore.ResolveReplace:
+		return lang.L("totp.duplicate.replace")
+	case totpstore.ResolveKeepBoth:
+		return lang.L("totp.duplicate.keepboth")
+	default:
+		return lang.L("totp.duplicate.skip")
+	}
+}
+
+// newDuplicateResolutionGroup は重複したエントリの処理方法を選択するラジオボタンを作成する（初期値はスキップ）
+func newDuplicateResolutionGroup() *widget.RadioGroup {
+	labels := make([]string, len(duplicateResolutions))
+	for i, resolution := range duplicateResolutions {
+		labels[i] = duplicateResolutionLabel(resolution)
+	}
+	group := widget.NewRadioGroup(labels, nil)
+	group.Required = true
+	group.SetSelected(duplicateResolutionLabel(totpstore.ResolveSkip))
+	return group
+}
+
+// selectedDuplicateResolution はラジオボタンで選択された処理方法を返す（重複がない場合はgroupがnil）
+func selectedDuplicateResolution(group *widget.RadioGroup) totpstore.DuplicateResolution {
+	if group == nil {
+		return totpstore.ResolveKeepBoth
+	}
+	for _, resolution := range duplicateResolutions {
+		if duplicateResolutionLabel(resolution) == group.Selected {
+			return resolution
+		}
+	}
+	return totpstore.ResolveSkip
+}
+
+// formatImportWarnings はインポート時の項目ごとの警告を表示用の文字列に整形する
+func formatImportWarnings(warnings []totpstore.ImportWarning) string {
+	return formatMigrationWarnings(warnings, "totp.migration.skipped")
+}
+
+// formatExportWarnings はエクスポート時の項目ごとの警告を表示用の文字列に整形する
+func formatExportWarnings(warnings []totpstore.ImportWarning) string {
+	return formatMigrationWarnings(warnings, "totp.migration.export.skipped")
+}
+
+// formatMigrationWarnings は項目ごとの警告を表示用の文字列に整形する（skippedKeyは除外した項目のメッセージのキー）
+func formatMigrationWarnings(warnings []totpstore.ImportWarning, skippedKey string) string {
+	lines := make([]string, 0, len(warnings)+1)
+	lines = append(lines, lang.L("totp.migration.warnings"))
+	for _, w := range warnings {
+		key := "totp.migration.warning"
+		if w.Skipped {
+			key = skippedKey
+		}
+		lines = append(lines, "- "+lang.L(key, M{"Name": w.Name, "Reason": importWarningReason(w.Err)}))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// importWarningReason はインポート・エクスポート時の警告の理由を表示用の文字列に変換する
+func importWarningReason(err error) string {
+	switch {
+	case errors.Is(err, totpstore.ErrUnspecifiedAlgorithm):
+		return lang.L("totp.migration.reason.unspecifiedalgorithm")
+	case errors.Is(err, totp.ErrUnsupportedAlgorithm):
+		return lang.L("totp.migration.reason.unsupportedalgorithm")
+	case errors.Is(err, totpstore.ErrUnsupportedOTPType):
+		return lang.L("totp.migration.reason.unsupportedtype")
+	case errors.Is(err, totpstore.ErrUnsupportedMigrationParams):
+		return lang.L("totp.migration.reason.unsupportedparams")
+	default:
+		return err.Error()
+	}
+}
+
 // showEntryFormDialog はエントリのフォームダイアログを表示する共通ヘルパー
+// コード生成パラメータとシークレットも編集でき、入力中の値で生成したコードをプレビューする
+// entryは変更せず、入力値を反映したコピーをonSaveに渡す（onSaveで保存まで行う）
+// シークレットは明示的に表示を切り替えた場合のみ表示する
 func (t *totpListTab) showEntryFormDialog(
 	entry *totpstore.Entry,
 	title, confirmLabel string,
@@ -275,6 +549,58 @@ func (t *totpListTab) showEntryFormDialog(
 	accountEntry := widget.NewEntry()
 	accountEntry.SetText(entry.Account)
 
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetPlaceHolder(lang.L("totp.edit.tags.placeholder"))
+	tagsEntry.SetText(strings.Join(entry.Tags, ", "))
+
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetText(entry.Secret)
+	secretEntry.Validator = func(s string) error {
+		if _, err := totpstore.ParseSecret(s); err != nil {
+			return errors.New(lang.L("totp.manual.secret.invalid"))
+		}
+		return nil
+	}
+
+	algorithmSelect := widget.NewSelect(withOption(manualAlgorithms, string(entry.Algorithm)), nil)
+	algorithmSelect.SetSelected(string(entry.Algorithm))
+
+	digitsSelect := widget.NewSelect(withOption(manualDigits, strconv.Itoa(entry.Digits)), nil)
+	digitsSelect.SetSelected(strconv.Itoa(entry.Digits))
+
+	periodEntry := widget.NewEntry()
+	periodEntry.SetText(strconv.Itoa(entry.Period))
+	periodEntry.Validator = validatePeriod
+	// HOTPは更新間隔を使用しない
+	if entry.IsHOTP() {
+		periodEntry.Disable()
+	}
+
+	// 入力中の値で生成したコードのプレビュー
+	previewLabel := widget.NewLabel("")
+	previewLabel.TextStyle = fyne.TextStyle{Monospace: true, Bold: true}
+	candidate := func() (*totpstore.Entry, error) {
+		return entryWithParams(entry, secretEntry.Text, algorithmSelect.Selected, digitsSelect.Selected, periodEntry.Text)
+	}
+	updatePreview := func() {
+		e, err := candidate()
+		if err != nil {
+			previewLabel.SetText(lang.L("totp.edit.preview.invalid"))
+			return
+		}
+		code, err := previewCode(e, t.store.Clock())
+		if err != nil {
+			previewLabel.SetText(lang.L("totp.edit.preview.invalid"))
+			return
+		}
+		previewLabel.SetText(formatCode(code))
+	}
+	secretEntry.OnChanged = func(string) { updatePreview() }
+	algorithmSelect.OnChanged = func(string) { updatePreview() }
+	digitsSelect.OnChanged = func(string) { updatePreview() }
+	periodEntry.OnChanged = func(string) { updatePreview() }
+	updatePreview()
+
 	form := dialog.NewForm(
 		title,
 		confirmLabel,
@@ -282,25 +608,67 @@ func (t *totpListTab) showEntryFormDialog(
 		[]*widget.FormItem{
 			widget.NewFormItem(lang.L("totp.edit.issuer"), issuerEntry),
 			widget.NewFormItem(lang.L("totp.edit.account"), accountEntry),
+			widget.NewFormItem(lang.L("totp.edit.tags"), tagsEntry),
+			widget.NewFormItem(lang.L("totp.manual.secret"), secretEntry),
+			widget.NewFormItem(lang.L("totp.details.algorithm"), algorithmSelect),
+			widget.NewFormItem(lang.L("totp.details.digits"), digitsSelect),
+			widget.NewFormItem(lang.L("totp.manual.period"), periodEntry),
+			widget.NewFormItem(lang.L("totp.edit.preview"), previewLabel),
 		},
 		func(confirmed bool) {
 			if !confirmed {
 				return
 			}
-			entry.Issuer = issuerEntry.Text
-			entry.Account = accountEntry.Text
-			if err := onSave(entry); err != nil {
+			// 入力値を検証したコピーを保存し、無効な場合や保存に失敗した場合はエントリを変更しない
+			updated, err := candidate()
+			if err != nil {
 				dialog.ShowError(err, t.app.mainWindow)
 				return
 			}
-			if err := t.store.Save(); err != nil {
+			updated.Issuer = issuerEntry.Text
+			updated.Account = accountEntry.Text
+			updated.SetTags(totpstore.ParseTags(tagsEntry.Text))
+			if err := onSave(updated); err != nil {
 				dialog.ShowError(err, t.app.mainWindow)
-				return
 			}
-			t.refreshEntries()
 		},
 		t.app.mainWindow,
 	)
-	form.Resize(fyne.NewSize(400, 200))
+
+	// ダイアログを開いている間はプレビューを毎秒更新する
+	ticker := time.NewTicker(time.Second)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(updatePreview)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	form.SetOnClosed(func() {
+		close(done)
+	})
+
+	form.Resize(fyne.NewSize(450, 500))
 	form.Show()
 }
+
+// previewCode はエントリの現在のコードを生成する（HOTPはカウンターを進めずに次のコードを生成する）
+func previewCode(entry *totpstore.Entry, clock totp.Clock) (string, error) {
+	if entry.IsHOTP() {
+		return entry.HOTP()
+	}
+	return entry.TOTP(clock)
+}
+
+// withOption は選択肢にvalueが含まれない場合は末尾に追加した選択肢を返す
+func withOption(options []string, value string) []string {
+	if value == "" || slices.Contains(options, value) {
+		return options
+	}
+	return append(slices.Clone(options), value)
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+	"github.com/nktmys/winticator/src/pkg/totp"
+	"github.com/nktmys/winticator/src/usecase/qrscanner"
+	"github.com/nktmys/winticator/src/usecase/totpstore"
+)
+
+// manualAlgorithms は手動追加で選択できるアルゴリズム（選択肢の表示順）
+var manualAlgorithms = []string{
+	string(totp.AlgorithmSHA1),
+	string(totp.AlgorithmSHA256),
+	string(totp.AlgorithmSHA512),
+	string(totp.AlgorithmMD5),
+}
+
+// manualDigits は手動追加で選択できる桁数（選択肢の表示順）
+var manualDigits = []string{"6", "7", "8"}
+
+// showAddMenu は追加方法（QRコードの読み取り・手動入力）のメニューを表示する
+func (t *totpListTab) showAddMenu(anchor fyne.CanvasObject) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem(lang.L("totp.add.scan"), func() {
+			t.scanQRCode()
+		}),
+		fyne.NewMenuItem(lang.L("totp.add.manual"), func() {
+			t.showManualAddDialog()
+		}),
+	)
+	popup := widget.NewPopUpMenu(menu, t.app.mainWindow.Canvas())
+	popup.ShowAtRelativePosition(fyne.NewPos(anchor.Size().Width-popup.Size().Width, anchor.Size().Height), anchor)
+}
+
+// showManualAddDialog はシークレットキーまたはotpauth URIを入力してエントリを追加するダイアログを表示する
+// URIが入力されている場合はURIを優先し、その他の項目は使用しない
+func (t *totpListTab) showManualAddDialog() {
+	uriEntry := widget.NewEntry()
+	uriEntry.SetPlaceHolder(lang.L("totp.manual.uri.placeholder"))
+	uriEntry.Validator = func(s string) error {
+		s = strings.TrimSpace(s)
+		if s == "" || strings.HasPrefix(s, "otpauth://") || strings.HasPrefix(s, "otpauth-migration://") {
+			return nil
+		}
+		return errors.New(lang.L("totp.manual.uri.invalid"))
+	}
+
+	issuerEntry := widget.NewEntry()
+	accountEntry := widget.NewEntry()
+
+	secretEntry := widget.NewEntry()
+	secretEntry.SetPlaceHolder(lang.L("totp.manual.secret.placeholder"))
+	secretEntry.Validator = func(s string) error {
+		// URIで追加する場合は空欄を許可する
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		if _, err := totpstore.ParseSecret(s); err != nil {
+			return errors.New(lang.L("totp.manual.secret.invalid"))
+		}
+		return nil
+	}
+
+	algorithmSelect := widget.NewSelect(manualAlgorithms, nil)
+	algorithmSelect.SetSelected(string(totp.AlgorithmSHA1))
+
+	digitsSelect := widget.NewSelect(manualDigits, nil)
+	digitsSelect.SetSelected(strconv.Itoa(totp.DefaultDigits))
+
+	periodEntry := widget.NewEntry()
+	periodEntry.SetText(strconv.Itoa(totp.DefaultPeriod))
+	periodEntry.Validator = validatePeriod
+
+	form := dialog.NewForm(
+		lang.L("totp.manual.title"),
+		lang.L("dialog.add"),
+		lang.L("dialog.cancel"),
+		[]*widget.FormItem{
+			widget.NewFormItem(lang.L("totp.edit.issuer"), issuerEntry),
+			widget.NewFormItem(lang.L("totp.edit.account"), accountEntry),
+			widget.NewFormItem(lang.L("totp.manual.secret"), secretEntry),
+			widget.NewFormItem(lang.L("totp.details.algorithm"), algorithmSelect),
+			widget.NewFormItem(lang.L("totp.details.digits"), digitsSelect),
+			widget.NewFormItem(lang.L("totp.manual.period"), periodEntry),
+			{
+				Text:     lang.L("totp.manual.uri"),
+				Widget:   uriEntry,
+				HintText: lang.L("totp.manual.uri.hint"),
+			},
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+
+			// URIが入力されている場合はQRコードの読み取りと同じ手順で追加する
+			if uri := strings.TrimSpace(uriEntry.Text); uri != "" {
+				t.addPastedURI(uri)
+				return
+			}
+
+			entry, err := newManualEntry(
+				issuerEntry.Text,
+				accountEntry.Text,
+				secretEntry.Text,
+				algorithmSelect.Selected,
+				digitsSelect.Selected,
+				periodEntry.Text,
+			)
+			if err != nil {
+				dialog.ShowError(err, t.app.mainWindow)
+				return
+			}
+			t.resolveDuplicate(entry, func(resolution totpstore.DuplicateResolution) {
+				t.addEntry(entry, resolution)
+			})
+		},
+		t.app.mainWindow,
+	)
+	form.Resize(fyne.NewSize(450, 450))
+	form.Show()
+}
+
+// validatePeriod は更新間隔の入力値が正の秒数かどうかを検証する
+func validatePeriod(s string) error {
+	if period, err := strconv.Atoi(strings.TrimSpace(s)); err != nil || period <= 0 {
+		return errors.New(lang.L("totp.manual.period.invalid"))
+	}
+	return nil
+}
+
+// newManualEntry は手動入力された値からエントリを作成して検証する
+func newManualEntry(issuer, account, secret, algorithm, digits, period string) (*totpstore.Entry, error) {
+	entry := totpstore.NewEntry(strings.TrimSpace(issuer), strings.TrimSpace(account), "")
+	return entryWithParams(entry, secret, algorithm, digits, period)
+}
+
+// entryWithParams はbaseのコピーに入力されたシークレットとコード生成パラメータを設定して検証する
+// シークレットは空白・小文字・パディングの有無を許容して正規化する（baseは変更しない）
+func entryWithParams(base *totpstore.Entry, secret, algorithm, digits, period string) (*totpstore.Entry, error) {
+	normalized, err := totpstore.ParseSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := base.Clone()
+	entry.Secret = normalized
+	if entry.Algorithm, err = totp.ParseAlgorithm(algorithm); err != nil {
+		return nil, err
+	}
+	if entry.Digits, err = strconv.Atoi(digits); err != nil {
+		return nil, totp.ErrInvalidDigits
+	}
+	if entry.Period, err = strconv.Atoi(strings.TrimSpace(period)); err != nil {
+		return nil, totp.ErrInvalidPeriod
+	}
+
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// addPastedURI は貼り付けたotpauth://・otpauth-migration:// URIからエントリを追加する
+func (t *totpListTab) addPastedURI(uri string) {
+	results, warnings, err := qrscanner.ParseURI(uri)
+	if err != nil {
+		errMsg := fmt.Sprintf("%s: %v", lang.L("totp.manual.uri.error"), err)
+		if errors.Is(err, totpstore.ErrNoTOTPEntries) {
+			errMsg = lang.L("totp.scan.nomigrationtotp")
+		}
+		if len(warnings) > 0 {
+			errMsg += "\n\n" + formatImportWarnings(warnings)
+		}
+		dialog.ShowError(errors.New(errMsg), t.app.mainWindow)
+		return
+	}
+	t.showScanResults(results, warnings)
+}
+
+// addEntry はエントリを追加して保存する（重複する場合はresolutionに従って追加する）
+// 保存できなかった場合は追加を取り消す
+func (t *totpListTab) addEntry(entry *totpstore.Entry, resolution totpstore.DuplicateResolution) {
+	err := t.store.Batch(func(tx *totpstore.Tx) error {
+		tx.AddResolved(entry, resolution)
+		return nil
+	})
+	if err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+	}
+}
@@ -1,7 +1,11 @@
 package ui
 
 import (
+	"errors"
 	"image/color"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -9,14 +13,36 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/nktmys/winticator/src/ui/custom"
 	"github.com/nktmys/winticator/src/ui/custom/components"
 	"github.com/nktmys/winticator/src/usecase/qrscanner"
 	"github.com/nktmys/winticator/src/usecase/totpstore"
 )
 
+const (
+	// codePlaceholder はコードを表示できない場合のプレースホルダー
+	codePlaceholder = "------"
+
+	// hotpClipboardClearDelay はHOTPコードをクリップボードからクリアするまでの時間
+	hotpClipboardClearDelay = 30 * time.Second
+
+	// totpClipboardClearMargin はTOTPコードの有効期限からクリップボードをクリアするまでの猶予
+	totpClipboardClearMargin = 3 * time.Second
+
+	// nextCodePreviewSeconds は次のコードを表示し始める残り秒数
+	nextCodePreviewSeconds = 5
+
+	// entryIconSize はリストに表示するエントリのアイコンのサイズ
+	entryIconSize = 36
+
+	// verifyWindow はコード検証時に前後を検索するステップ数
+	verifyWindow = 10
+)
+
 // createListItem はリストアイテムのテンプレートを作成する
 func (t *totpListTab) createListItem() fyne.CanvasObject {
 	// 表示名（Account または Issuer）
@@ -25,22 +51,36 @@ func (t *totpListTab) createListItem() fyne.CanvasObject {
 	// TOTPコード（青色・大きいフォント）
 	codeText := components.NewStyledText("000 000", custom.ColorPrimaryBlue, 28)
 
+	// 次のTOTPコード（残り時間が少ない場合のみ表示）
+	nextCodeText := components.NewStyledText("000 000", custom.ColorPrimaryGray, 16)
+	nextCodeText.Hide()
+
 	// 左パディング用のスペーサーを追加（widget.LabelのInnerPaddingに合わせる）
 	codePadding := canvas.NewRectangle(color.Transparent)
 	codePadding.SetMinSize(fyne.NewSize(theme.InnerPadding(), 0))
-	paddedCode := container.NewHBox(codePadding, codeText)
+	paddedCode := container.NewHBox(codePadding, codeText, nextCodeText)
 
 	// 円形プログレス
 	circularProgress := components.NewCircularProgress(32)
 
+	// 次のコード生成ボタン（HOTP用）
+	nextButton := widget.NewButtonWithIcon("", theme.MediaSkipNextIcon(), nil)
+	nextButton.Hide()
+
 	// メニューボタン
 	menuButton := widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), nil)
 
-	// 左側: 表示名 + コード（パディング付き）
-	leftContent := container.NewVBox(displayNameLabel, paddedCode)
+	// エントリのアイコン（未設定の場合は汎用アイコン）
+	iconImage := canvas.NewImageFromResource(theme.AccountIcon())
+	iconImage.FillMode = canvas.ImageFillContain
+	iconImage.SetMinSize(fyne.NewSize(entryIconSize, entryIconSize))
+
+	// 左側: アイコン + 表示名 + コード（パディング付き）
+	textContent := container.NewVBox(displayNameLabel, paddedCode)
+	leftContent := container.NewHBox(container.NewCenter(iconImage), textContent)
 
-	// 右側: 円形プログレス + メニュー
-	rightContent := container.NewHBox(circularProgress, menuButton)
+	// 右側: 円形プログレス（TOTP）または次のコード生成ボタン（HOTP） + メニュー
+	rightContent := container.NewHBox(circularProgress, nextButton, menuButton)
 
 	content := container.NewBorder(nil, nil, leftContent, rightContent)
 	return components.NewHoverBlocker(content)
@@ -58,34 +98,71 @@ func (t *totpListTab) updateListItem(id widget.ListItemID, item fyne.CanvasObjec
 
 	// 左側のコンテンツを取得
 	leftContent, _ := border.Objects[0].(*fyne.Container)
-	displayNameLabel, _ := leftContent.Objects[0].(*widget.Label)
-	paddedCode, _ := leftContent.Objects[1].(*fyne.Container)
+	iconBox, _ := leftContent.Objects[0].(*fyne.Container)
+	iconImage, _ := iconBox.Objects[0].(*canvas.Image)
+	textContent, _ := leftContent.Objects[1].(*fyne.Container)
+	displayNameLabel, _ := textContent.Objects[0].(*widget.Label)
+	paddedCode, _ := textContent.Objects[1].(*fyne.Container)
 	codeText, _ := paddedCode.Objects[1].(*components.StyledText)
+	nextCodeText, _ := paddedCode.Objects[2].(*components.StyledText)
 
 	// 右側のコンテンツを取得
 	rightBox, _ := border.Objects[1].(*fyne.Container)
 	circularProgress, _ := rightBox.Objects[0].(*components.CircularProgress)
-	menuButton, _ := rightBox.Objects[1].(*widget.Button)
+	nextButton, _ := rightBox.Objects[1].(*widget.Button)
+	menuButton, _ := rightBox.Objects[2].(*widget.Button)
 	// テーマ変更時にアイコンが更新されるようにする
 	menuButton.SetIcon(theme.MoreHorizontalIcon())
 
-	// 表示名を設定
+	// 表示名とアイコンを設定
 	displayNameLabel.SetText(entry.DisplayName())
+	// ピン留めしたエントリは強調表示する
+	if displayNameLabel.TextStyle.Bold != entry.Pinned {
+		displayNameLabel.TextStyle.Bold = entry.Pinned
+		displayNameLabel.Refresh()
+	}
+	if icon := t.iconResource(entry); iconImage.Resource != icon {
+		iconImage.Resource = icon
+		iconImage.Refresh()
+	}
+
+	// メニューボタン
+	entryCopy := entry
+	index := id
+	menuButton.OnTapped = func() {
+		t.showEntryMenu(entryCopy, menuButton, index)
+	}
+
+	// HOTPはカウントダウンの代わりに次のコード生成ボタンを表示
+	if entry.IsHOTP() {
+		nextCodeText.Hide()
+		t.updateHOTPListItem(entry, codeText, circularProgress, nextButton)
+		return
+	}
+	nextButton.Hide()
+	circularProgress.Show()
 
-	// TOTPコードを生成
-	code, err := entry.TOTP()
+	// 現在時刻のコードと残り時間を生成（時刻オフセットを反映したClockを使用）
+	clock := t.store.Clock()
+	remaining := entry.RemainingSeconds(clock)
+	snapshot, err := entry.Snapshot(clock)
 	if err != nil {
-		codeText.SetText("------")
+		codeText.SetText(codePlaceholder)
+		nextCodeText.Hide()
 	} else {
-		// 3桁ごとにスペースを挿入
-		if len(code) == 6 {
-			code = code[:3] + " " + code[3:]
+		remaining = snapshot.RemainingSeconds()
+		codeText.SetText(formatCode(snapshot.Code))
+
+		// 残り時間が少ない場合は次のコードを表示
+		if remaining < nextCodePreviewSeconds {
+			nextCodeText.SetText("→ " + formatCode(snapshot.Next))
+			nextCodeText.Show()
+		} else {
+			nextCodeText.Hide()
 		}
-		codeText.SetText(code)
 	}
 
 	// 残り時間を設定
-	remaining := entry.RemainingSeconds()
 	circularProgress.Max = float64(entry.Period)
 	circularProgress.SetValue(float64(remaining))
 
@@ -99,26 +176,100 @@ func (t *totpListTab) updateListItem(id widget.ListItemID, item fyne.CanvasObjec
 		codeText.SetColor(normalColor)
 		circularProgress.SetColor(normalColor)
 	}
+}
+
+// updateHOTPListItem はHOTPエントリのリストアイテムを更新する
+func (t *totpListTab) updateHOTPListItem(
+	entry *totpstore.Entry,
+	codeText *components.StyledText,
+	circularProgress *components.CircularProgress,
+	nextButton *widget.Button,
+) {
+	circularProgress.Hide()
+	nextButton.SetIcon(theme.MediaSkipNextIcon())
+	nextButton.Show()
+
+	// 最後に生成したコードを表示（未生成の場合はプレースホルダー）
+	if code, ok := t.hotpCodes[entry.ID]; ok {
+		codeText.SetText(formatCode(code))
+	} else {
+		codeText.SetText(codePlaceholder)
+	}
+	codeText.SetColor(custom.ColorPrimaryBlue)
 
-	// メニューボタン
 	entryCopy := entry
-	index := id
-	total := len(t.filteredEntries)
-	menuButton.OnTapped = func() {
-		t.showEntryMenu(entryCopy, menuButton, index, total)
+	nextButton.OnTapped = func() {
+		t.nextHOTP(entryCopy)
+	}
+}
+
+// nextHOTP はHOTPエントリの次のコードを生成し、カウンターを保存する
+func (t *totpListTab) nextHOTP(entry *totpstore.Entry) (string, bool) {
+	code, err := t.store.NextHOTP(entry.ID)
+	if err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+		return "", false
+	}
+	t.hotpCodes[entry.ID] = code
+	t.list.Refresh()
+	return code, true
+}
+
+// formatCode は表示用にコードを整形する（6桁の場合は3桁ごとにスペースを挿入）
+func formatCode(code string) string {
+	if len(code) == 6 {
+		return code[:3] + " " + code[3:]
 	}
+	return code
 }
 
 // copyCode はTOTPコードをクリップボードにコピーする
 func (t *totpListTab) copyCode(entry *totpstore.Entry) {
-	code, err := entry.TOTP()
+	if entry.IsHOTP() {
+		t.copyHOTPCode(entry)
+		return
+	}
+
+	// コードと有効期限を同じ時刻から求める
+	snapshot, err := entry.Snapshot(t.store.Clock())
 	if err != nil {
 		return
 	}
+	code := snapshot.Code
+	validFor := snapshot.Remaining()
+	message := lang.L("totp.copied.message")
+
+	// 残り時間がしきい値未満の場合は次のコードをコピー
+	if threshold := t.app.preferences.GetCopyNextThreshold(); threshold > 0 && snapshot.RemainingSeconds() < threshold {
+		code = snapshot.Next
+		validFor += time.Duration(entry.Period) * time.Second
+		message = lang.L("totp.copied.next")
+	}
+
+	// クリップボードにコピーし、有効期限+猶予時間後にクリアをスケジュール
+	t.clipboard.Copy(code, validFor+totpClipboardClearMargin)
+	t.recordUse(entry)
+
+	// トースト通知を表示
+	components.ShowToast(t.app.mainWindow, message)
+}
+
+// recordUse はエントリの使用履歴を記録する（記録に失敗してもコピーは妨げない）
+func (t *totpListTab) recordUse(entry *totpstore.Entry) {
+	_ = t.store.RecordUse(entry.ID)
+}
+
+// copyHOTPCode は表示中のHOTPコードをコピーする（未生成の場合は次のコードを生成する）
+func (t *totpListTab) copyHOTPCode(entry *totpstore.Entry) {
+	code, ok := t.hotpCodes[entry.ID]
+	if !ok {
+		if code, ok = t.nextHOTP(entry); !ok {
+			return
+		}
+	}
 
-	// クリップボードにコピーし、有効時間+3秒後にクリアをスケジュール
-	remaining := time.Duration(entry.RemainingSeconds()+3) * time.Second
-	t.clipboard.Copy(code, remaining)
+	t.clipboard.Copy(code, hotpClipboardClearDelay)
+	t.recordUse(entry)
 
 	// トースト通知を表示
 	components.ShowToast(
@@ -128,20 +279,20 @@ func (t *totpListTab) copyCode(entry *totpstore.Entry) {
 }
 
 // showEntryMenu はエントリのメニューを表示する
-func (t *totpListTab) showEntryMenu(entry *totpstore.Entry, anchor fyne.CanvasObject, index int, total int) {
+func (t *totpListTab) showEntryMenu(entry *totpstore.Entry, anchor fyne.CanvasObject, index int) {
 	var items []*fyne.MenuItem
 
-	// 検索中でなければ移動メニューを表示
-	if !t.isSearching() {
-		// 先頭でなければ「上へ移動」を表示
-		if index > 0 {
+	// 検索中でなく、手動の並び順の場合は移動メニューを表示
+	if !t.isSearching() && t.sortMode == totpstore.SortManual {
+		// セクション（ピン留め/通常）の先頭でなければ「上へ移動」を表示
+		if t.canMoveEntry(index, -1) {
 			items = append(items, fyne.NewMenuItem(lang.L("totp.menu.moveup"), func() {
 				t.moveEntry(entry.ID, -1)
 			}))
 		}
 
-		// 末尾でなければ「下へ移動」を表示
-		if index < total-1 {
+		// セクション（ピン留め/通常）の末尾でなければ「下へ移動」を表示
+		if t.canMoveEntry(index, +1) {
 			items = append(items, fyne.NewMenuItem(lang.L("totp.menu.movedown"), func() {
 				t.moveEntry(entry.ID, +1)
 			}))
@@ -153,13 +304,38 @@ func (t *totpListTab) showEntryMenu(entry *totpstore.Entry, anchor fyne.CanvasOb
 		}
 	}
 
+	pinLabel := lang.L("totp.menu.pin")
+	if entry.Pinned {
+		pinLabel = lang.L("totp.menu.unpin")
+	}
 	items = append(items,
+		fyne.NewMenuItem(pinLabel, func() {
+			t.togglePinned(entry)
+		}),
+		fyne.NewMenuItem(lang.L("totp.menu.details"), func() {
+			t.showDetailsDialog(entry)
+		}),
 		fyne.NewMenuItem(lang.L("totp.menu.edit"), func() {
 			t.showEditDialog(entry)
 		}),
+		fyne.NewMenuItem(lang.L("totp.menu.seticon"), func() {
+			t.showIconPicker(entry)
+		}),
+	)
+	if entry.HasIcon() {
+		items = append(items, fyne.NewMenuItem(lang.L("totp.menu.removeicon"), func() {
+			updated := entry.Clone()
+			updated.ClearIcon()
+			t.saveEntry(updated)
+		}))
+	}
+	items = append(items,
 		fyne.NewMenuItem(lang.L("totp.menu.showqr"), func() {
 			t.showQRCode(entry)
 		}),
+		fyne.NewMenuItem(lang.L("totp.menu.verify"), func() {
+			t.showVerifyDialog(entry)
+		}),
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem(lang.L("totp.menu.delete"), func() {
 			t.confirmDelete(entry)
@@ -172,7 +348,28 @@ func (t *totpListTab) showEntryMenu(entry *totpstore.Entry, anchor fyne.CanvasOb
 	popup.ShowAtRelativePosition(rel, anchor)
 }
 
-// moveEntry はエントリを指定方向に移動する（direction: -1=上, +1=下）
+// canMoveEntry は指定位置のエントリを同じセクション内で指定方向に移動できるかを返す
+func (t *totpListTab) canMoveEntry(index int, direction int) bool {
+	swapIdx := index + direction
+	if index < 0 || index >= len(t.entries) || swapIdx < 0 || swapIdx >= len(t.entries) {
+		return false
+	}
+	return t.entries[index].Pinned == t.entries[swapIdx].Pinned
+}
+
+// togglePinned はエントリのピン留めを切り替える
+func (t *totpListTab) togglePinned(entry *totpstore.Entry) {
+	if err := t.store.SetPinned(entry.ID, !entry.Pinned); err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+		return
+	}
+	if err := t.store.Save(); err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+		return
+	}
+}
+
+// moveEntry はエントリを同じセクション内で指定方向に移動する（direction: -1=上, +1=下）
 func (t *totpListTab) moveEntry(id string, direction int) {
 	// 現在のエントリからIDリストを生成
 	ids := make([]string, len(t.entries))
@@ -188,21 +385,18 @@ func (t *totpListTab) moveEntry(id string, direction int) {
 		return
 	}
 
-	// 隣接エントリと入れ替え
-	swapIdx := targetIdx + direction
-	if swapIdx < 0 || swapIdx >= len(ids) {
+	// 同じセクションの隣接エントリと入れ替え
+	if !t.canMoveEntry(targetIdx, direction) {
 		return
 	}
+	swapIdx := targetIdx + direction
 	ids[targetIdx], ids[swapIdx] = ids[swapIdx], ids[targetIdx]
 
-	// 永続化してリスト更新
+	// 永続化（リストはストアの変更イベントで更新される）
 	if err := t.store.Reorder(ids); err != nil {
 		return
 	}
-	if err := t.store.Save(); err != nil {
-		return
-	}
-	t.refreshEntries()
+	_ = t.store.Save()
 }
 
 // showEditDialog は編集ダイアログを表示する
@@ -212,9 +406,131 @@ func (t *totpListTab) showEditDialog(entry *totpstore.Entry) {
 		lang.L("totp.edit.title"),
 		lang.L("dialog.save"),
 		func(e *totpstore.Entry) error {
-			return t.store.Update(e)
+			return t.store.Batch(func(tx *totpstore.Tx) error {
+				return tx.Update(e)
+			})
+		},
+	)
+}
+
+// iconResource はエントリのアイコンのリソースを返す（未設定の場合は汎用アイコン）
+// 毎秒の更新で画像を比較・再読み込みしないよう、アイコンのキーが変わるまで同じリソースを使用する
+func (t *totpListTab) iconResource(entry *totpstore.Entry) fyne.Resource {
+	if !entry.HasIcon() {
+		delete(t.iconResources, entry.ID)
+		return theme.AccountIcon()
+	}
+
+	// 描画キャッシュが内容ごとに区別されるよう、リソース名にIDとアイコンのキーを含める
+	ext := ".png"
+	if entry.IconType == totpstore.IconTypeSVG {
+		ext = ".svg"
+	}
+	name := entry.ID + "-" + entry.IconKey() + ext
+	if res, ok := t.iconResources[entry.ID]; ok && res.Name() == name {
+		return res
+	}
+	res := fyne.NewStaticResource(name, entry.Icon)
+	t.iconResources[entry.ID] = res
+	return res
+}
+
+// showIconPicker はアイコン画像（PNG/SVG）を選択するダイアログを表示する
+func (t *totpListTab) showIconPicker(entry *totpstore.Entry) {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, t.app.mainWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(err, t.app.mainWindow)
+			return
+		}
+		updated := entry.Clone()
+		if err := updated.SetIcon(data); err != nil {
+			dialog.ShowError(err, t.app.mainWindow)
+			return
+		}
+		t.saveEntry(updated)
+	}, t.app.mainWindow)
+
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".png", ".svg"}))
+	openDialog.Show()
+}
+
+// saveEntry は変更したエントリのコピーで登録済みのエントリを置き換えて保存する
+// 保存に失敗した場合は登録済みのエントリを変更しない
+func (t *totpListTab) saveEntry(updated *totpstore.Entry) {
+	err := t.store.Batch(func(tx *totpstore.Tx) error {
+		return tx.Update(updated)
+	})
+	if err != nil {
+		dialog.ShowError(err, t.app.mainWindow)
+	}
+}
+
+// showDetailsDialog はエントリの詳細とメモを表示する（メモは編集して保存できる）
+func (t *totpListTab) showDetailsDialog(entry *totpstore.Entry) {
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetPlaceHolder(lang.L("totp.details.notes.placeholder"))
+	notesEntry.Wrapping = fyne.TextWrapWord
+	notesEntry.SetMinRowsVisible(5)
+	notesEntry.SetText(entry.Notes)
+
+	otpType := "TOTP"
+	step := widget.NewFormItem(lang.L("totp.details.period"), widget.NewLabel(lang.L("totp.details.seconds", M{"Seconds": entry.Period})))
+	if entry.IsHOTP() {
+		otpType = "HOTP"
+		step = widget.NewFormItem(lang.L("totp.details.counter"), widget.NewLabel(strconv.FormatUint(entry.Counter, 10)))
+	}
+
+	tags := strings.Join(entry.Tags, ", ")
+	if tags == "" {
+		tags = "-"
+	}
+
+	lastUsed := "-"
+	if !entry.LastUsedAt.IsZero() {
+		lastUsed = entry.LastUsedAt.Local().Format(time.DateTime)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem(lang.L("totp.edit.issuer"), widget.NewLabel(entry.Issuer)),
+		widget.NewFormItem(lang.L("totp.edit.account"), widget.NewLabel(entry.Account)),
+		widget.NewFormItem(lang.L("totp.details.type"), widget.NewLabel(otpType)),
+		widget.NewFormItem(lang.L("totp.details.algorithm"), widget.NewLabel(string(entry.Algorithm))),
+		widget.NewFormItem(lang.L("totp.details.digits"), widget.NewLabel(strconv.Itoa(entry.Digits))),
+		step,
+		widget.NewFormItem(lang.L("totp.edit.tags"), widget.NewLabel(tags)),
+		widget.NewFormItem(lang.L("totp.details.created"), widget.NewLabel(entry.CreatedAt.Local().Format(time.DateTime))),
+		widget.NewFormItem(lang.L("totp.details.lastused"), widget.NewLabel(lastUsed)),
+		widget.NewFormItem(lang.L("totp.details.usecount"), widget.NewLabel(strconv.Itoa(entry.UseCount))),
+		widget.NewFormItem(lang.L("totp.details.notes"), notesEntry),
+	)
+
+	details := dialog.NewCustomConfirm(
+		lang.L("totp.details.title"),
+		lang.L("dialog.save"),
+		lang.L("dialog.close"),
+		form,
+		func(save bool) {
+			if !save || notesEntry.Text == entry.Notes {
+				return
+			}
+			updated := entry.Clone()
+			updated.Notes = notesEntry.Text
+			t.saveEntry(updated)
 		},
+		t.app.mainWindow,
 	)
+	details.Resize(fyne.NewSize(450, 450))
+	details.Show()
 }
 
 // showQRCode はQRコードを表示する
@@ -245,6 +561,53 @@ func (t *totpListTab) showQRCode(entry *totpstore.Entry) {
 	)
 }
 
+// showVerifyDialog はコード検証ダイアログを表示する
+func (t *totpListTab) showVerifyDialog(entry *totpstore.Entry) {
+	codeEntry := widget.NewEntry()
+	codeEntry.PlaceHolder = lang.L("totp.verify.placeholder")
+
+	form := dialog.NewForm(
+		lang.L("totp.verify.title"),
+		lang.L("totp.verify.check"),
+		lang.L("dialog.cancel"),
+		[]*widget.FormItem{
+			widget.NewFormItem(entry.DisplayName(), codeEntry),
+		},
+		func(confirmed bool) {
+			if !confirmed || codeEntry.Text == "" {
+				return
+			}
+			t.showVerifyResult(entry, codeEntry.Text)
+		},
+		t.app.mainWindow,
+	)
+	form.Resize(fyne.NewSize(400, 160))
+	form.Show()
+}
+
+// showVerifyResult はコード検証結果を表示する
+func (t *totpListTab) showVerifyResult(entry *totpstore.Entry, code string) {
+	offset, err := entry.Verify(code, verifyWindow, t.store.Clock())
+	if err != nil {
+		if errors.Is(err, totp.ErrCodeMismatch) {
+			err = errors.New(lang.L("totp.verify.mismatch", M{"Window": verifyWindow}))
+		}
+		dialog.ShowError(err, t.app.mainWindow)
+		return
+	}
+
+	var message string
+	switch {
+	case offset == 0:
+		message = lang.L("totp.verify.match")
+	case entry.IsHOTP():
+		message = lang.L("totp.verify.counteroffset", M{"Offset": offset})
+	default:
+		message = lang.L("totp.verify.drift", M{"Offset": offset, "Seconds": offset * entry.Period})
+	}
+	dialog.ShowInformation(lang.L("totp.verify.title"), message, t.app.mainWindow)
+}
+
 // confirmDelete は削除確認ダイアログを表示する
 func (t *totpListTab) confirmDelete(entry *totpstore.Entry) {
 	dialog.ShowConfirm(
@@ -262,7 +625,6 @@ func (t *totpListTab) confirmDelete(entry *totpstore.Entry) {
 				dialog.ShowError(err, t.app.mainWindow)
 				return
 			}
-			t.refreshEntries()
 		},
 		t.app.mainWindow,
 	)
@@ -10,12 +10,18 @@ const (
 	keyThemeVariant = "themeVariant"
 	keyLanguage     = "language"
 	keyTOTPData     = "totpData"
+	keyTimeOffset   = "timeOffset"
+	keyCopyNext     = "copyNextThreshold"
+	keySortMode     = "sortMode"
+	keyVaultPath    = "vaultPath"
+	keyTrashDays    = "trashRetentionDays"
 )
 
 // デフォルト値（非公開）
 var (
 	defaultThemeVariant = int(theme.VariantLight) // Lightをデフォルトに
 	defaultLanguage     = ""                      // 空文字列はシステムロケールを使用
+	defaultTrashDays    = 30                      // ゴミ箱のエントリを30日で完全削除
 )
 
 // Manager はアプリケーション設定を管理する
@@ -59,3 +65,55 @@ func (m *Manager) GetTOTPData() string {
 func (m *Manager) SetTOTPData(data string) {
 	m.preferences.SetString(keyTOTPData, data)
 }
+
+// GetTimeOffset はコード生成時に加算する時刻オフセット（秒）を取得する
+func (m *Manager) GetTimeOffset() int {
+	return m.preferences.IntWithFallback(keyTimeOffset, 0)
+}
+
+// SetTimeOffset はコード生成時に加算する時刻オフセット（秒）を保存する
+func (m *Manager) SetTimeOffset(seconds int) {
+	m.preferences.SetInt(keyTimeOffset, seconds)
+}
+
+// GetCopyNextThreshold は次のコードをコピーする残り秒数のしきい値を取得する
+// 0の場合は常に現在のコードをコピーする
+func (m *Manager) GetCopyNextThreshold() int {
+	return m.preferences.IntWithFallback(keyCopyNext, 0)
+}
+
+// SetCopyNextThreshold は次のコードをコピーする残り秒数のしきい値を保存する
+func (m *Manager) SetCopyNextThreshold(seconds int) {
+	m.preferences.SetInt(keyCopyNext, seconds)
+}
+
+// GetSortMode はTOTPリストの並び順を取得する（空文字列は手動の並び順）
+func (m *Manager) GetSortMode() string {
+	return m.preferences.String(keySortMode)
+}
+
+// SetSortMode はTOTPリストの並び順を保存する
+func (m *Manager) SetSortMode(mode string) {
+	m.preferences.SetString(keySortMode, mode)
+}
+
+// GetVaultPath は保存データファイルのパスを取得する（空文字列は既定のパス）
+func (m *Manager) GetVaultPath() string {
+	return m.preferences.String(keyVaultPath)
+}
+
+// SetVaultPath は保存データファイルのパスを保存する
+func (m *Manager) SetVaultPath(path string) {
+	m.preferences.SetString(keyVaultPath, path)
+}
+
+// GetTrashRetentionDays はゴミ箱のエントリを自動的に完全削除するまでの日数を取得する
+// 0の場合は自動的に削除しない
+func (m *Manager) GetTrashRetentionDays() int {
+	return m.preferences.IntWithFallback(keyTrashDays, defaultTrashDays)
+}
+
+// SetTrashRetentionDays はゴミ箱のエントリを自動的に完全削除するまでの日数を保存する
+func (m *Manager) SetTrashRetentionDays(days int) {
+	m.preferences.SetInt(keyTrashDays, days)
+}
@@ -18,8 +18,8 @@ var (
 	// ErrNoQRCodeFound はQRコードが見つからない場合のエラー
 	ErrNoQRCodeFound = errors.New("no QR code found in the captured image")
 
-	// ErrNoTOTPQRFound はTOTP用のQRコードが見つからない場合のエラー
-	ErrNoTOTPQRFound = errors.New("no TOTP QR code found (otpauth://totp/...)")
+	// ErrNoTOTPQRFound はTOTP/HOTP用のQRコードが見つからない場合のエラー
+	ErrNoTOTPQRFound = errors.New("no OTP QR code found (otpauth://totp/... or otpauth://hotp/...)")
 
 	// ErrScreenCaptureFailed は画面キャプチャに失敗した場合のエラー
 	ErrScreenCaptureFailed = errors.New("failed to capture screen")
@@ -32,11 +32,12 @@ type ScanResult struct {
 }
 
 // CaptureAndScan は画面全体をキャプチャしてQRコードをスキャンする
-func CaptureAndScan() ([]ScanResult, error) {
+// otpauth-migration URIの場合は項目ごとの警告（取り込まなかった項目を含む）も返す
+func CaptureAndScan() ([]ScanResult, []totpstore.ImportWarning, error) {
 	// 画面をキャプチャ
 	img, err := captureScreen()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// QRコードをデコード
@@ -158,46 +159,54 @@ func decodeQRCode(img image.Image) (string, error) {
 	return "", ErrNoQRCodeFound
 }
 
-// scanQRCodes は画像からQRコードを検出してTOTPエントリを返す
-func scanQRCodes(img image.Image) ([]ScanResult, error) {
+// scanQRCodes は画像からQRコードを検出してTOTPエントリと項目ごとの警告を返す
+func scanQRCodes(img image.Image) ([]ScanResult, []totpstore.ImportWarning, error) {
 	// マルチスケールフォールバックでQRコードをデコード
 	uri, err := decodeQRCode(img)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	return ParseURI(uri)
+}
+
+// ParseURI はQRコードの内容や貼り付けたotpauth://・otpauth-migration:// URIをパースする
+// 前後の空白は無視し、otpauth-migration URIの場合は項目ごとの警告も返す
+func ParseURI(uri string) ([]ScanResult, []totpstore.ImportWarning, error) {
+	uri = strings.TrimSpace(uri)
+
 	switch {
 	// otpauth-migration:// URI（Google Authenticatorエクスポート形式）
 	case strings.HasPrefix(uri, "otpauth-migration://"):
-		entries, err := totpstore.ParseOTPAuthMigrationURI(uri)
+		entries, warnings, err := totpstore.ParseOTPAuthMigrationURIWithWarnings(uri)
 		if err != nil {
-			return nil, err
+			return nil, warnings, err
 		}
 		results := make([]ScanResult, len(entries))
 		for i, entry := range entries {
 			results[i] = ScanResult{Entry: entry, URI: uri}
 		}
-		return results, nil
+		return results, warnings, nil
 
-	// otpauth://totp/ URI（標準TOTP形式）
-	case strings.HasPrefix(uri, "otpauth://totp/"):
+	// otpauth://totp/ または otpauth://hotp/ URI（標準TOTP/HOTP形式）
+	case strings.HasPrefix(uri, "otpauth://totp/"), strings.HasPrefix(uri, "otpauth://hotp/"):
 		entry, err := totpstore.ParseOTPAuthURI(uri)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		return []ScanResult{
 			{
 				Entry: entry,
 				URI:   uri,
 			},
-		}, nil
+		}, nil, nil
 
 	default:
-		return nil, ErrNoTOTPQRFound
+		return nil, nil, ErrNoTOTPQRFound
 	}
 }
 
 // ScanImage は指定した画像からQRコードをスキャンする（テスト用）
-func ScanImage(img image.Image) ([]ScanResult, error) {
+func ScanImage(img image.Image) ([]ScanResult, []totpstore.ImportWarning, error) {
 	return scanQRCodes(img)
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/makiuchi-d/gozxing"
 	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/nktmys/winticator/src/usecase/totpstore/migration"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -50,7 +51,7 @@ func TestScanImage_ValidTOTPQR(t *testing.T) {
 	require.NoError(t, err)
 
 	// スキャン
-	results, err := ScanImage(img)
+	results, _, err := ScanImage(img)
 	require.NoError(t, err)
 	require.Len(t, results, 1)
 
@@ -61,13 +62,28 @@ func TestScanImage_ValidTOTPQR(t *testing.T) {
 	assert.Equal(t, "JBSWY3DPEHPK3PXP", result.Entry.Secret)
 }
 
+func TestScanImage_ValidHOTPQR(t *testing.T) {
+	uri := "otpauth://hotp/VPN:user?secret=JBSWY3DPEHPK3PXP&issuer=VPN&counter=3"
+	img, err := generateQRImage(uri)
+	require.NoError(t, err)
+
+	results, _, err := ScanImage(img)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	entry := results[0].Entry
+	assert.Equal(t, "VPN", entry.Issuer)
+	assert.True(t, entry.IsHOTP())
+	assert.Equal(t, uint64(3), entry.Counter)
+}
+
 func TestScanImage_NonTOTPQR(t *testing.T) {
 	// 非TOTPのQRコード画像を生成
 	img, err := generateQRImage("https://example.com")
 	require.NoError(t, err)
 
 	// スキャン
-	_, err = ScanImage(img)
+	_, _, err = ScanImage(img)
 	assert.ErrorIs(t, err, ErrNoTOTPQRFound)
 }
 
@@ -83,7 +99,7 @@ func TestScanImage_NoQRCode(t *testing.T) {
 	}
 
 	// スキャン
-	_, err := ScanImage(img)
+	_, _, err := ScanImage(img)
 	assert.ErrorIs(t, err, ErrNoQRCodeFound)
 }
 
@@ -93,7 +109,7 @@ func TestScanImage_ComplexURI(t *testing.T) {
 	img, err := generateQRImage(uri)
 	require.NoError(t, err)
 
-	results, err := ScanImage(img)
+	results, _, err := ScanImage(img)
 	require.NoError(t, err)
 	require.Len(t, results, 1)
 
@@ -101,7 +117,7 @@ func TestScanImage_ComplexURI(t *testing.T) {
 	assert.Equal(t, "GitHub", entry.Issuer)
 	assert.Equal(t, "myaccount", entry.Account)
 	assert.Equal(t, "ABCDEFGHIJKLMNOP", entry.Secret)
-	assert.Equal(t, "SHA256", entry.Algorithm)
+	assert.Equal(t, totp.AlgorithmSHA256, entry.Algorithm)
 	assert.Equal(t, 8, entry.Digits)
 	assert.Equal(t, 60, entry.Period)
 }
@@ -136,17 +152,68 @@ func TestScanImage_MigrationQR(t *testing.T) {
 	require.NoError(t, err)
 
 	// スキャン
-	results, err := ScanImage(img)
+	results, _, err := ScanImage(img)
 	require.NoError(t, err)
 	require.Len(t, results, 2)
 
 	assert.Equal(t, "Google", results[0].Entry.Issuer)
 	assert.Equal(t, "user@gmail.com", results[0].Entry.Account)
-	assert.Equal(t, "SHA1", results[0].Entry.Algorithm)
+	assert.Equal(t, totp.AlgorithmSHA1, results[0].Entry.Algorithm)
 	assert.Equal(t, 6, results[0].Entry.Digits)
 
 	assert.Equal(t, "GitHub", results[1].Entry.Issuer)
 	assert.Equal(t, "myaccount", results[1].Entry.Account)
-	assert.Equal(t, "SHA256", results[1].Entry.Algorithm)
+	assert.Equal(t, totp.AlgorithmSHA256, results[1].Entry.Algorithm)
 	assert.Equal(t, 8, results[1].Entry.Digits)
 }
+
+func TestScanImage_MigrationQRWarnings(t *testing.T) {
+	payload := &migration.MigrationPayload{
+		OtpParameters: []*migration.MigrationPayload_OtpParameters{
+			{
+				Secret:    []byte("12345678901234567890"),
+				Name:      "Google:user@gmail.com",
+				Issuer:    "Google",
+				Algorithm: migration.MigrationPayload_SHA1,
+				Type:      migration.MigrationPayload_TOTP,
+			},
+			{
+				Secret:    []byte("abcdefghijklmnopqrst"),
+				Name:      "Legacy:user",
+				Issuer:    "Legacy",
+				Algorithm: migration.MigrationPayload_Algorithm(99),
+				Type:      migration.MigrationPayload_TOTP,
+			},
+		},
+	}
+	data, err := proto.Marshal(payload)
+	require.NoError(t, err)
+
+	uri := "otpauth-migration://offline?data=" + base64.StdEncoding.EncodeToString(data)
+	img, err := generateQRImage(uri)
+	require.NoError(t, err)
+
+	// 未知のアルゴリズムの項目は取り込まず、警告として返す
+	results, warnings, err := ScanImage(img)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Google", results[0].Entry.Issuer)
+
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "Legacy: user", warnings[0].Name)
+	assert.True(t, warnings[0].Skipped)
+	assert.ErrorIs(t, warnings[0], totp.ErrUnsupportedAlgorithm)
+}
+
+func TestParseURI(t *testing.T) {
+	// 貼り付けた文字列の前後の空白は無視する
+	results, warnings, err := ParseURI("  otpauth://totp/GitHub:user?secret=JBSWY3DPEHPK3PXP&issuer=GitHub\n")
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	require.Len(t, results, 1)
+	assert.Equal(t, "GitHub", results[0].Entry.Issuer)
+	assert.Equal(t, "user", results[0].Entry.Account)
+
+	_, _, err = ParseURI("https://example.com")
+	assert.ErrorIs(t, err, ErrNoTOTPQRFound)
+}
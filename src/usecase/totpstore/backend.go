@@ -0,0 +1,110 @@
+package totpstore
+
+import (
+	"encoding/base64"
+	"slices"
+	"sync"
+
+	"github.com/nktmys/winticator/src/usecase/preferences"
+)
+
+// Backend は暗号化済みの保存データの読み書き先
+type Backend interface {
+	// Load は保存データを読み込む（保存データがない場合はnilを返す）
+	Load() ([]byte, error)
+	// Save は保存データを書き込む
+	Save(data []byte) error
+	// Clear は保存データを削除する
+	Clear() error
+}
+
+// PreferencesBackend はFyneのPreferencesに保存データをBase64文字列として保存するBackend
+type PreferencesBackend struct {
+	prefs *preferences.Manager
+}
+
+// NewPreferencesBackend は新しいPreferencesBackendを作成する
+func NewPreferencesBackend(prefs *preferences.Manager) *PreferencesBackend {
+	return &PreferencesBackend{prefs: prefs}
+}
+
+// Load は保存データを読み込む
+func (b *PreferencesBackend) Load() ([]byte, error) {
+	encoded := b.prefs.GetTOTPData()
+	if encoded == "" {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+// Save は保存データを書き込む
+func (b *PreferencesBackend) Save(data []byte) error {
+	b.prefs.SetTOTPData(base64.StdEncoding.EncodeToString(data))
+	return nil
+}
+
+// Clear は保存データを削除する
+func (b *PreferencesBackend) Clear() error {
+	b.prefs.SetTOTPData("")
+	return nil
+}
+
+// MemoryBackend はメモリ上に保存データを保持するBackend（テスト用）
+type MemoryBackend struct {
+	data []byte
+	mu   sync.Mutex
+}
+
+// NewMemoryBackend は新しいMemoryBackendを作成する
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+// Load は保存データを読み込む
+func (b *MemoryBackend) Load() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.data), nil
+}
+
+// Save は保存データを書き込む
+func (b *MemoryBackend) Save(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = slices.Clone(data)
+	return nil
+}
+
+// Clear は保存データを削除する
+func (b *MemoryBackend) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = nil
+	return nil
+}
+
+// MigrateBackend はfromの保存データをtoに移行し、移行した場合はtrueを返す
+// toに保存データがある場合やfromに保存データがない場合は何もしない
+// toへの書き込みが成功した場合のみfromの保存データを削除する
+func MigrateBackend(from, to Backend) (bool, error) {
+	existing, err := to.Load()
+	if err != nil {
+		return false, err
+	}
+	if len(existing) > 0 {
+		return false, nil
+	}
+
+	data, err := from.Load()
+	if err != nil {
+		return false, err
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+
+	if err := to.Save(data); err != nil {
+		return false, err
+	}
+	return true, from.Clear()
+}
@@ -0,0 +1,264 @@
+package totpstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/nktmys/winticator/src/usecase/preferences"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackends(t *testing.T) {
+	backends := map[string]func(t *testing.T) Backend{
+		"preferences": func(t *testing.T) Backend {
+			return NewPreferencesBackend(preferences.New(newMockPreferences()))
+		},
+		"file": func(t *testing.T) Backend {
+			return NewFileBackend(filepath.Join(t.TempDir(), "nested", VaultFileName))
+		},
+		"memory": func(t *testing.T) Backend {
+			return NewMemoryBackend()
+		},
+	}
+
+	for name, newBackend := range backends {
+		t.Run(name, func(t *testing.T) {
+			backend := newBackend(t)
+
+			// 保存データがない場合はnilを返す
+			data, err := backend.Load()
+			require.NoError(t, err)
+			assert.Empty(t, data)
+
+			require.NoError(t, backend.Save([]byte{0x00, 0x01, 0xfe, 0xff}))
+			data, err = backend.Load()
+			require.NoError(t, err)
+			assert.Equal(t, []byte{0x00, 0x01, 0xfe, 0xff}, data)
+
+			require.NoError(t, backend.Clear())
+			data, err = backend.Load()
+			require.NoError(t, err)
+			assert.Empty(t, data)
+
+			// 保存データがない状態で削除してもエラーにしない
+			require.NoError(t, backend.Clear())
+		})
+	}
+}
+
+func TestFileBackend_Permissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), VaultFileName)
+	backend := NewFileBackend(path)
+	require.NoError(t, backend.Save([]byte("data")))
+	assert.Equal(t, path, backend.Path())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if os.PathSeparator == '/' {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestMigrateBackend(t *testing.T) {
+	machinekey.ResetCache()
+
+	// Preferencesに保存された既存のデータ
+	prefs := preferences.New(newMockPreferences())
+	legacy := New(prefs)
+	require.NoError(t, legacy.Load())
+	require.NoError(t, legacy.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.NoError(t, legacy.Save())
+
+	from := NewPreferencesBackend(prefs)
+	to := NewFileBackend(filepath.Join(t.TempDir(), VaultFileName))
+
+	migrated, err := MigrateBackend(from, to)
+	require.NoError(t, err)
+	assert.True(t, migrated)
+	assert.Empty(t, prefs.GetTOTPData())
+
+	// 移行先のデータをそのまま読み込めること
+	store := NewWithBackend(to)
+	require.NoError(t, store.Load())
+	entries := store.GetAll()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "GitHub", entries[0].Issuer)
+
+	// 移行済みの場合は何もしない
+	migrated, err = MigrateBackend(from, to)
+	require.NoError(t, err)
+	assert.False(t, migrated)
+}
+
+func TestMigrateBackend_KeepsExistingDestination(t *testing.T) {
+	from := NewMemoryBackend()
+	to := NewMemoryBackend()
+	require.NoError(t, from.Save([]byte("old")))
+	require.NoError(t, to.Save([]byte("current")))
+
+	migrated, err := MigrateBackend(from, to)
+	require.NoError(t, err)
+	assert.False(t, migrated)
+
+	data, err := to.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("current"), data)
+}
+
+func TestFileBackend_Required(t *testing.T) {
+	backend := NewFileBackend(filepath.Join(t.TempDir(), "missing", VaultFileName))
+	backend.SetRequired(true)
+
+	// 指定された保存データファイルがない場合は初回起動として扱わない
+	_, err := backend.Load()
+	require.ErrorIs(t, err, ErrVaultNotFound)
+
+	// 保存した後は読み込める
+	require.NoError(t, backend.Save([]byte("data")))
+	data, err := backend.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+}
+
+func TestStore_MigrateFrom(t *testing.T) {
+	machinekey.ResetCache()
+
+	prefs := preferences.New(newMockPreferences())
+	legacy := New(prefs)
+	require.NoError(t, legacy.Load())
+	require.NoError(t, legacy.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.NoError(t, legacy.Save())
+
+	// 読み込み時に以前の保存先のデータを移行する
+	backend := NewFileBackend(filepath.Join(t.TempDir(), VaultFileName))
+	store := NewWithBackend(backend)
+	store.MigrateFrom(NewPreferencesBackend(prefs))
+	require.NoError(t, store.Load())
+	assert.Equal(t, 1, store.Count())
+	assert.Empty(t, prefs.GetTOTPData())
+}
+
+func TestStore_MigrateFrom_Failure(t *testing.T) {
+	machinekey.ResetCache()
+
+	from := NewMemoryBackend()
+	require.NoError(t, from.Save([]byte("legacy vault data")))
+
+	// 移行できない場合は空のストアで保存できるようにせず、復旧モードにする
+	store := NewWithBackend(&failingBackend{fail: true})
+	store.MigrateFrom(from)
+	require.ErrorIs(t, store.Load(), errSaveFailed)
+	failure := store.LoadFailure()
+	require.NotNil(t, failure)
+	assert.ErrorIs(t, failure.Err, errSaveFailed)
+
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.ErrorIs(t, store.Save(), ErrVaultLocked)
+
+	// 移行元のデータは残る
+	data, err := from.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("legacy vault data"), data)
+}
+
+func TestStore_Load_MissingRequiredVault(t *testing.T) {
+	machinekey.ResetCache()
+
+	backend := NewFileBackend(filepath.Join(t.TempDir(), "missing", VaultFileName))
+	backend.SetRequired(true)
+
+	// ユーザーが指定した保存データファイルが見つからない場合は新しい保存データとして扱わない
+	store := NewWithBackend(backend)
+	require.ErrorIs(t, store.Load(), ErrVaultNotFound)
+	require.NotNil(t, store.LoadFailure())
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.ErrorIs(t, store.Save(), ErrVaultLocked)
+}
+
+func TestStore_SwitchBackend(t *testing.T) {
+	machinekey.ResetCache()
+
+	from := NewMemoryBackend()
+	store := NewWithBackend(from)
+	require.NoError(t, store.Load())
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.NoError(t, store.Save())
+
+	to := NewFileBackend(filepath.Join(t.TempDir(), VaultFileName))
+	cleanupErr, err := store.SwitchBackend(to)
+	require.NoError(t, err)
+	require.NoError(t, cleanupErr)
+	assert.Same(t, to, store.Backend())
+
+	// 元の保存先のデータは削除される
+	data, err := from.Load()
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	loaded := NewWithBackend(to)
+	require.NoError(t, loaded.Load())
+	assert.Equal(t, 1, loaded.Count())
+}
+
+// unclearableBackend は保存データの削除に失敗するテスト用のBackend
+type unclearableBackend struct {
+	MemoryBackend
+}
+
+var errClearFailed = errors.New("clear failed")
+
+func (b *unclearableBackend) Clear() error {
+	return errClearFailed
+}
+
+func TestStore_SwitchBackend_ClearFailure(t *testing.T) {
+	machinekey.ResetCache()
+
+	from := &unclearableBackend{}
+	store := NewWithBackend(from)
+	require.NoError(t, store.Load())
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.NoError(t, store.Save())
+
+	// 元の保存先を削除できなくても切り替えは成功する
+	to := NewMemoryBackend()
+	cleanupErr, err := store.SwitchBackend(to)
+	require.NoError(t, err)
+	assert.ErrorIs(t, cleanupErr, errClearFailed)
+	assert.Same(t, to, store.Backend())
+}
+
+func TestStore_SwitchBackend_ImportsSnapshots(t *testing.T) {
+	machinekey.ResetCache()
+
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	from := NewFileBackend(filepath.Join(t.TempDir(), VaultFileName))
+	from.now = func() time.Time { return now }
+	store := NewWithBackend(from)
+	require.NoError(t, store.Load())
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.NoError(t, store.Save())
+	require.NoError(t, from.Snapshot())
+	snapshots, err := from.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	to := NewFileBackend(filepath.Join(t.TempDir(), VaultFileName))
+	cleanupErr, err := store.SwitchBackend(to)
+	require.NoError(t, err)
+	require.NoError(t, cleanupErr)
+
+	// スナップショットは新しい保存先に引き継がれ、復元できる
+	imported, err := to.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, imported, 1)
+	assert.Equal(t, snapshots[0].ID, imported[0].ID)
+	entries, err := store.SnapshotEntries(imported[0].ID)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
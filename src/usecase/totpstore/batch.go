@@ -0,0 +1,143 @@
+package totpstore
+
+import (
+	"slices"
+)
+
+// Tx はBatchの中でStoreを変更する操作
+// 変更はBatchに渡した関数が成功し、保存できた場合のみ反映される（Batchの外では使用できない）
+type Tx struct {
+	store *Store
+}
+
+// Add は新しいエントリを追加する
+func (tx *Tx) Add(entry *Entry) {
+	tx.store.add(entry)
+	tx.store.emit(EventAdded, entry.ID)
+}
+
+// AddResolved はエントリを追加し、重複する場合はresolutionに従って処理する
+// エントリを追加または置き換えた場合はtrue、スキップした場合はfalseを返す
+func (tx *Tx) AddResolved(entry *Entry, resolution DuplicateResolution) bool {
+	return tx.store.addResolved(entry, resolution)
+}
+
+// Update は既存のエントリを更新する
+func (tx *Tx) Update(entry *Entry) error {
+	return tx.store.update(entry)
+}
+
+// Delete は指定したIDのエントリをゴミ箱に移動する
+func (tx *Tx) Delete(id string) error {
+	return tx.store.delete(id)
+}
+
+// DeleteAll は全てのエントリをゴミ箱に移動する
+func (tx *Tx) DeleteAll() {
+	for _, entry := range slices.Clone(tx.store.entries) {
+		_ = tx.store.delete(entry.ID)
+	}
+}
+
+// Snapshot は変更前の保存データのスナップショットを作成する（スナップショットに対応していないBackendでは何もしない）
+// ロールバックはメモリ上の状態のみを戻すため、インポートなどの大きな変更の前に呼び出して保存データの復元ポイントを残す
+// 保存時に作成するスナップショットと同じ内容の場合は重複して作成しない
+func (tx *Tx) Snapshot() error {
+	backend, ok := tx.store.backend.(SnapshotBackend)
+	if !ok {
+		return nil
+	}
+	return backend.Snapshot()
+}
+
+// AbandonRecovery は読み込めなかった保存データの復旧をあきらめ、保存できるようにする
+// バックアップからの復元など、変更を保存できた場合のみ復旧モードを解除する場合に使用する
+func (tx *Tx) AbandonRecovery() {
+	tx.store.failure = nil
+}
+
+// Batch は複数の変更をまとめて適用して保存する
+// fnがエラーを返した場合や保存に失敗した場合は、すべての変更を取り消してエラーを返す
+// 変更イベントは保存に成功した場合のみ通知する
+// 取り消しはメモリ上の状態に対して行うため、保存データの復元ポイントが必要な場合はfnの最初でTx.Snapshotを呼び出す
+func (s *Store) Batch(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	checkpoint := s.checkpoint()
+	if err := fn(&Tx{store: s}); err != nil {
+		s.rollback(checkpoint)
+		return err
+	}
+	if err := s.save(); err != nil {
+		s.rollback(checkpoint)
+		return err
+	}
+	return nil
+}
+
+// AddMany は複数のエントリをまとめて追加して保存し、追加または置き換えた件数を返す
+// 重複する場合はresolutionに従って処理し、追加する前に保存データのスナップショットを作成する
+func (s *Store) AddMany(entries []*Entry, resolution DuplicateResolution) (int, error) {
+	added := 0
+	err := s.Batch(func(tx *Tx) error {
+		if err := tx.Snapshot(); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if tx.AddResolved(entry, resolution) {
+				added++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// storeCheckpoint はBatchの変更を取り消すために記録したStoreの状態
+type storeCheckpoint struct {
+	entries []*Entry
+	trash   []*Entry
+	values  map[*Entry]Entry // 変更前のエントリの内容（フィールドを直接変更する操作を取り消すため）
+	pending int              // 通知待ちの変更イベントの件数
+	failure *LoadFailure     // 復旧モードの状態
+}
+
+// checkpoint は現在のStoreの状態を記録する（ロックは呼び出し側で取得する）
+func (s *Store) checkpoint() storeCheckpoint {
+	// Generatorのキャッシュはストアのロックの外から更新されるため、コピー中はgeneratorMuを取得する
+	generatorMu.Lock()
+	defer generatorMu.Unlock()
+
+	values := make(map[*Entry]Entry, len(s.entries)+len(s.trash))
+	for _, entry := range s.entries {
+		values[entry] = *entry
+	}
+	for _, entry := range s.trash {
+		values[entry] = *entry
+	}
+	return storeCheckpoint{
+		entries: slices.Clone(s.entries),
+		trash:   slices.Clone(s.trash),
+		values:  values,
+		pending: len(s.pending),
+		failure: s.failure,
+	}
+}
+
+// rollback は記録した状態にStoreを戻し、取り消した変更のイベントを破棄する（ロックは呼び出し側で取得する）
+func (s *Store) rollback(checkpoint storeCheckpoint) {
+	generatorMu.Lock()
+	for entry, value := range checkpoint.values {
+		*entry = value
+	}
+	generatorMu.Unlock()
+
+	s.entries = checkpoint.entries
+	s.trash = checkpoint.trash
+	s.pending = s.pending[:checkpoint.pending]
+	s.failure = checkpoint.failure
+}
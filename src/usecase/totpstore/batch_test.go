@@ -0,0 +1,218 @@
+package totpstore
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingBackend は保存に失敗するテスト用のBackend
+type failingBackend struct {
+	MemoryBackend
+	fail bool
+}
+
+var errSaveFailed = errors.New("save failed")
+
+func (b *failingBackend) Save(data []byte) error {
+	if b.fail {
+		return errSaveFailed
+	}
+	return b.MemoryBackend.Save(data)
+}
+
+// newBatchTestStore は2件のエントリを保存したテスト用のStoreを作成する
+func newBatchTestStore(t *testing.T) (*Store, *failingBackend, []*Entry) {
+	t.Helper()
+	machinekey.ResetCache()
+
+	backend := &failingBackend{}
+	store := NewWithBackend(backend)
+	require.NoError(t, store.Load())
+	entries := []*Entry{
+		NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP"),
+		NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ"),
+	}
+	for _, entry := range entries {
+		require.NoError(t, store.Add(entry))
+	}
+	require.NoError(t, store.Save())
+	return store, backend, entries
+}
+
+func TestStore_Batch(t *testing.T) {
+	store, backend, existing := newBatchTestStore(t)
+	events := recordEvents(t, store)
+
+	added := NewEntry("Microsoft", "user", "KRSXG5CTMVRXEZLU")
+	err := store.Batch(func(tx *Tx) error {
+		tx.Add(added)
+		return tx.Delete(existing[0].ID)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, store.Count())
+	assert.Equal(t, 1, store.TrashCount())
+	assert.Equal(t, []Event{
+		{Type: EventAdded, IDs: []string{added.ID}},
+		{Type: EventDeleted, IDs: []string{existing[0].ID}},
+	}, *events)
+
+	// 変更は保存されている
+	loaded := NewWithBackend(backend)
+	require.NoError(t, loaded.Load())
+	assert.Equal(t, 2, loaded.Count())
+	assert.Equal(t, 1, loaded.TrashCount())
+}
+
+func TestStore_Batch_RollbackOnError(t *testing.T) {
+	store, _, existing := newBatchTestStore(t)
+	events := recordEvents(t, store)
+
+	updated := *existing[1]
+	updated.Issuer = "Changed"
+	err := store.Batch(func(tx *Tx) error {
+		tx.Add(NewEntry("Microsoft", "user", "KRSXG5CTMVRXEZLU"))
+		require.NoError(t, tx.Update(&updated))
+		require.NoError(t, tx.Delete(existing[0].ID))
+		return tx.Delete("missing")
+	})
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+
+	// すべての変更が取り消され、イベントも通知されない
+	all := store.GetAll()
+	require.Len(t, all, 2)
+	assert.Equal(t, existing[0].ID, all[0].ID)
+	assert.True(t, all[0].DeletedAt.IsZero())
+	assert.Equal(t, "Google", all[1].Issuer)
+	assert.Equal(t, 0, store.TrashCount())
+	assert.Empty(t, *events)
+}
+
+func TestStore_Batch_RollbackOnSaveError(t *testing.T) {
+	store, backend, existing := newBatchTestStore(t)
+	events := recordEvents(t, store)
+
+	backend.fail = true
+	err := store.Batch(func(tx *Tx) error {
+		tx.DeleteAll()
+		tx.Add(NewEntry("Microsoft", "user", "KRSXG5CTMVRXEZLU"))
+		return nil
+	})
+	assert.ErrorIs(t, err, errSaveFailed)
+
+	all := store.GetAll()
+	require.Len(t, all, 2)
+	assert.Equal(t, existing[0].ID, all[0].ID)
+	assert.Equal(t, existing[1].ID, all[1].ID)
+	assert.Equal(t, 0, store.TrashCount())
+	assert.Empty(t, *events)
+}
+
+func TestStore_Batch_RollbackWhileGenerating(t *testing.T) {
+	store, _, existing := newBatchTestStore(t)
+
+	// ロールバックでエントリを書き戻している間もコードを生成できる（-raceで競合しないこと）
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_, err := existing[0].TOTP(fixedClock(59))
+				assert.NoError(t, err)
+			}
+		}
+	})
+	for range 5000 {
+		err := store.Batch(func(tx *Tx) error {
+			return ErrEntryNotFound
+		})
+		require.ErrorIs(t, err, ErrEntryNotFound)
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestStore_AddMany(t *testing.T) {
+	store, backend, _ := newBatchTestStore(t)
+
+	added, err := store.AddMany([]*Entry{
+		NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP"),
+		NewEntry("Microsoft", "user", "KRSXG5CTMVRXEZLU"),
+	}, ResolveSkip)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 3, store.Count())
+
+	// 保存に失敗した場合は1件も追加しない
+	backend.fail = true
+	added, err = store.AddMany([]*Entry{NewEntry("Amazon", "user", "MFRGGZDFMZTWQ2LK")}, ResolveSkip)
+	assert.ErrorIs(t, err, errSaveFailed)
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 3, store.Count())
+}
+
+func TestTx_DeleteAll(t *testing.T) {
+	store, _, existing := newBatchTestStore(t)
+
+	imported := []*Entry{
+		NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP"),
+		NewEntry("Microsoft", "user", "KRSXG5CTMVRXEZLU"),
+	}
+	require.NoError(t, store.Batch(func(tx *Tx) error {
+		tx.DeleteAll()
+		for _, entry := range imported {
+			tx.AddResolved(entry, ResolveKeepBoth)
+		}
+		return nil
+	}))
+
+	all := store.GetAll()
+	require.Len(t, all, 2)
+	assert.Equal(t, imported[0].ID, all[0].ID)
+	assert.Equal(t, imported[1].ID, all[1].ID)
+
+	// 置き換えたエントリはゴミ箱から復元できる
+	assert.Len(t, store.Trash(), len(existing))
+}
+
+func TestTx_Snapshot(t *testing.T) {
+	machinekey.ResetCache()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	backend := newTestFileBackend(t, &now)
+	store := NewWithBackend(backend)
+	require.NoError(t, store.Load())
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.NoError(t, store.Save())
+	before, err := store.Snapshots()
+	require.NoError(t, err)
+
+	// 追加する前の保存データのスナップショットを1つだけ作成する
+	now = now.Add(time.Minute)
+	_, err = store.AddMany([]*Entry{NewEntry("Microsoft", "user", "KRSXG5CTMVRXEZLU")}, ResolveSkip)
+	require.NoError(t, err)
+
+	snapshots, err := store.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, len(before)+1)
+	entries, err := store.SnapshotEntries(snapshots[0].ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "GitHub", entries[0].Issuer)
+
+	// スナップショットに対応していないBackendでは何もしない
+	memory := NewWithBackend(NewMemoryBackend())
+	require.NoError(t, memory.Load())
+	assert.NoError(t, memory.Batch(func(tx *Tx) error {
+		return tx.Snapshot()
+	}))
+}
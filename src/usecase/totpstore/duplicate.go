@@ -0,0 +1,143 @@
+package totpstore
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/rs/xid"
+)
+
+// DuplicateResolution は重複したエントリを追加する場合の処理方法
+type DuplicateResolution int
+
+// 重複したエントリの処理方法
+const (
+	ResolveSkip     DuplicateResolution = iota // 追加しない
+	ResolveReplace                             // 既存のエントリを置き換える（IDと表示順序、ユーザーが設定した情報は引き継ぐ）
+	ResolveKeepBoth                            // 両方を残す（IDが重複する場合は新しいIDを割り当てる）
+)
+
+// IsDuplicateOf はエントリが他のエントリと重複しているかどうかを返す
+// IDが同じ場合、または正規化したシークレット・サービス名・アカウント名が同じ場合に重複とみなす
+func (e *Entry) IsDuplicateOf(other *Entry) bool {
+	if e.ID != "" && e.ID == other.ID {
+		return true
+	}
+	return NormalizeSecret(e.Secret) == NormalizeSecret(other.Secret) &&
+		strings.EqualFold(strings.TrimSpace(e.Issuer), strings.TrimSpace(other.Issuer)) &&
+		strings.EqualFold(strings.TrimSpace(e.Account), strings.TrimSpace(other.Account))
+}
+
+// FindDuplicate は指定したエントリと重複する登録済みのエントリを返す
+func (s *Store) FindDuplicate(entry *Entry) (*Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	index := s.duplicateIndex(entry)
+	if index < 0 {
+		return nil, false
+	}
+	return s.entries[index], true
+}
+
+// CountDuplicates は指定したエントリのうち登録済みのエントリ、または先に並ぶエントリと重複する件数を返す
+func (s *Store) CountDuplicates(entries []*Entry) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for i, entry := range entries {
+		if s.duplicateIndex(entry) >= 0 || containsDuplicate(entries[:i], entry) {
+			count++
+		}
+	}
+	return count
+}
+
+// AddResolved はエントリを追加し、重複する場合はresolutionに従って処理する
+// エントリを追加または置き換えた場合はtrue、スキップした場合はfalseを返す
+func (s *Store) AddResolved(entry *Entry, resolution DuplicateResolution) bool {
+	s.mu.Lock()
+	defer s.unlock()
+
+	return s.addResolved(entry, resolution)
+}
+
+// addResolved はエントリを追加し、重複する場合はresolutionに従って処理する（ロックは呼び出し側で取得する）
+func (s *Store) addResolved(entry *Entry, resolution DuplicateResolution) bool {
+	index := s.duplicateIndex(entry)
+	if index < 0 {
+		s.add(entry)
+		s.emit(EventAdded, entry.ID)
+		return true
+	}
+
+	switch resolution {
+	case ResolveReplace:
+		existing := s.entries[index]
+		entry.ID = existing.ID
+		entry.Order = existing.Order
+		entry.inheritUserData(existing)
+		s.entries[index] = entry
+		s.emit(EventUpdated, entry.ID)
+		return true
+	case ResolveKeepBoth:
+		if entry.ID == s.entries[index].ID {
+			entry.ID = xid.New().String()
+		}
+		s.add(entry)
+		s.emit(EventAdded, entry.ID)
+		return true
+	default:
+		return false
+	}
+}
+
+// inheritUserData は置き換える既存のエントリからユーザーが設定した情報と使用履歴を引き継ぐ
+// ピン留めとタグは両方を合わせ、メモとアイコンは置き換えるエントリにない場合のみ引き継ぐ
+func (e *Entry) inheritUserData(existing *Entry) {
+	e.Pinned = e.Pinned || existing.Pinned
+	e.SetTags(slices.Concat(existing.Tags, e.Tags))
+	if e.Notes == "" {
+		e.Notes = existing.Notes
+	}
+	if !e.HasIcon() && existing.HasIcon() {
+		e.Icon = bytes.Clone(existing.Icon)
+		e.IconType = existing.IconType
+		e.iconKey = existing.iconKey
+	}
+	if existing.LastUsedAt.After(e.LastUsedAt) {
+		e.LastUsedAt = existing.LastUsedAt
+	}
+	e.UseCount = max(e.UseCount, existing.UseCount)
+	if !existing.CreatedAt.IsZero() {
+		e.CreatedAt = existing.CreatedAt
+	}
+}
+
+// duplicateIndex は重複する登録済みのエントリの位置を返す（ロックは呼び出し側で取得する）
+// IDが同じエントリを優先し、見つからない場合は-1を返す
+func (s *Store) duplicateIndex(entry *Entry) int {
+	for i, e := range s.entries {
+		if entry.ID != "" && e.ID == entry.ID {
+			return i
+		}
+	}
+	for i, e := range s.entries {
+		if entry.IsDuplicateOf(e) {
+			return i
+		}
+	}
+	return -1
+}
+
+// containsDuplicate はentriesに指定したエントリと重複するエントリが含まれるかどうかを返す
+func containsDuplicate(entries []*Entry, entry *Entry) bool {
+	for _, e := range entries {
+		if entry.IsDuplicateOf(e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package totpstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/nktmys/winticator/src/usecase/preferences"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSecret(t *testing.T) {
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", NormalizeSecret("jbsw y3dp-ehpk 3pxp"))
+	assert.Equal(t, "JBSWY3DP", NormalizeSecret("JBSWY3DP===="))
+}
+
+func TestEntry_IsDuplicateOf(t *testing.T) {
+	entry := NewEntry("Google", "user@example.com", "JBSWY3DPEHPK3PXP")
+
+	// シークレット・サービス名・アカウント名は正規化して比較する
+	same := NewEntry(" google ", "USER@example.com", "jbsw y3dp ehpk 3pxp")
+	assert.True(t, entry.IsDuplicateOf(same))
+
+	// IDが同じ場合は内容に関わらず重複とみなす
+	sameID := NewEntry("Other", "other", "GEZDGNBVGY3TQOJQ")
+	sameID.ID = entry.ID
+	assert.True(t, entry.IsDuplicateOf(sameID))
+
+	assert.False(t, entry.IsDuplicateOf(NewEntry("Google", "other@example.com", "JBSWY3DPEHPK3PXP")))
+	assert.False(t, entry.IsDuplicateOf(NewEntry("Google", "user@example.com", "GEZDGNBVGY3TQOJQ")))
+}
+
+func TestStore_AddResolved(t *testing.T) {
+	machinekey.ResetCache()
+
+	newStore := func(t *testing.T) (*Store, *Entry) {
+		store := New(preferences.New(newMockPreferences()))
+		require.NoError(t, store.Load())
+		require.NoError(t, store.Add(NewEntry("Other", "user", "GEZDGNBVGY3TQOJQ")))
+		existing := NewEntry("Google", "user", "JBSWY3DPEHPK3PXP")
+		require.NoError(t, store.Add(existing))
+		return store, existing
+	}
+
+	t.Run("not duplicate", func(t *testing.T) {
+		store, _ := newStore(t)
+		added := store.AddResolved(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP"), ResolveSkip)
+		assert.True(t, added)
+		assert.Equal(t, 3, store.Count())
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		store, existing := newStore(t)
+		added := store.AddResolved(NewEntry("Google", "user", "JBSWY3DPEHPK3PXP"), ResolveSkip)
+		assert.False(t, added)
+		assert.Equal(t, 2, store.Count())
+
+		got, err := store.Get(existing.ID)
+		require.NoError(t, err)
+		assert.Same(t, existing, got)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		store, existing := newStore(t)
+		replacement := NewEntry("Google", "user", "JBSWY3DPEHPK3PXP")
+		replacement.Digits = 8
+		added := store.AddResolved(replacement, ResolveReplace)
+		assert.True(t, added)
+		assert.Equal(t, 2, store.Count())
+
+		// IDと表示順序を引き継いで置き換える
+		got, err := store.Get(existing.ID)
+		require.NoError(t, err)
+		assert.Same(t, replacement, got)
+		assert.Equal(t, existing.Order, got.Order)
+		assert.Equal(t, 8, got.Digits)
+	})
+
+	t.Run("replace keeps user data", func(t *testing.T) {
+		store, existing := newStore(t)
+		lastUsed := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+		existing.Pinned = true
+		existing.SetTags([]string{"work"})
+		existing.Notes = "recovery codes in the safe"
+		require.NoError(t, existing.SetIcon([]byte(testSVG)))
+		existing.LastUsedAt = lastUsed
+		existing.UseCount = 5
+
+		replacement := NewEntry("Google", "user", "JBSWY3DPEHPK3PXP")
+		replacement.SetTags([]string{"personal"})
+		assert.True(t, store.AddResolved(replacement, ResolveReplace))
+
+		// ユーザーが設定した情報と使用履歴は引き継ぎ、タグは両方を合わせる
+		assert.True(t, replacement.Pinned)
+		assert.Equal(t, []string{"work", "personal"}, replacement.Tags)
+		assert.Equal(t, "recovery codes in the safe", replacement.Notes)
+		assert.Equal(t, []byte(testSVG), replacement.Icon)
+		assert.Equal(t, existing.IconKey(), replacement.IconKey())
+		assert.True(t, lastUsed.Equal(replacement.LastUsedAt))
+		assert.Equal(t, 5, replacement.UseCount)
+		assert.True(t, existing.CreatedAt.Equal(replacement.CreatedAt))
+	})
+
+	t.Run("keep both", func(t *testing.T) {
+		store, existing := newStore(t)
+
+		// IDが重複する場合は新しいIDを割り当てる
+		copied := *existing
+		copied.generator = nil
+		added := store.AddResolved(&copied, ResolveKeepBoth)
+		assert.True(t, added)
+		assert.Equal(t, 3, store.Count())
+		assert.NotEqual(t, existing.ID, copied.ID)
+	})
+}
+
+func TestStore_CountDuplicates(t *testing.T) {
+	machinekey.ResetCache()
+
+	store := New(preferences.New(newMockPreferences()))
+	require.NoError(t, store.Load())
+	require.NoError(t, store.Add(NewEntry("Google", "user", "JBSWY3DPEHPK3PXP")))
+
+	// 同じQRコードを2回読み取った場合も重複として数える
+	entries := []*Entry{
+		NewEntry("Google", "user", "JBSWY3DPEHPK3PXP"),
+		NewEntry("GitHub", "user", "GEZDGNBVGY3TQOJQ"),
+		NewEntry("GitHub", "user", "GEZDGNBVGY3TQOJQ"),
+	}
+	assert.Equal(t, 2, store.CountDuplicates(entries))
+}
@@ -1,47 +1,99 @@
 package totpstore
 
 import (
+	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/rs/xid"
 )
 
-const algorithmSHA1 = "SHA1"
+// steamDigits はSteam Guardコードの桁数
+const steamDigits = 5
+
+// OTPの種別
+const (
+	TypeTOTP = "totp" // 時刻ベース (RFC 6238)
+	TypeHOTP = "hotp" // カウンターベース (RFC 4226)
+)
 
 // Entry はTOTPエントリを表す構造体
 type Entry struct {
-	ID        string    `json:"id"`         // UUID
-	Issuer    string    `json:"issuer"`     // サービス名 (例: "Google")
-	Account   string    `json:"account"`    // アカウント名 (例: "user@gmail.com")
-	Secret    string    `json:"secret"`     // Base32シークレットキー
-	Algorithm string    `json:"algorithm"`  // "SHA1", "SHA256", "SHA512"
-	Digits    int       `json:"digits"`     // 6 または 8
-	Period    int       `json:"period"`     // 秒単位 (通常30)
-	Order     int       `json:"order"`      // 表示順序
-	CreatedAt time.Time `json:"created_at"` // 登録日時
+	ID         string         `json:"id"`                    // UUID
+	Issuer     string         `json:"issuer"`                // サービス名 (例: "Google")
+	Account    string         `json:"account"`               // アカウント名 (例: "user@gmail.com")
+	Type       string         `json:"type,omitempty"`        // "totp" または "hotp" (空はtotp)
+	Secret     string         `json:"secret"`                // Base32シークレットキー
+	Algorithm  totp.Algorithm `json:"algorithm"`             // "SHA1", "SHA256", "SHA512", "MD5"
+	Digits     int            `json:"digits"`                // 6 または 8
+	Period     int            `json:"period"`                // 秒単位 (通常30)
+	T0         int64          `json:"t0,omitempty"`          // TOTPのカウント開始Unix時刻 (通常0)
+	Counter    uint64         `json:"counter,omitempty"`     // HOTPの次回カウンター値
+	Encoder    string         `json:"encoder,omitempty"`     // コードのエンコーダー名 (空は10進数, "steam"等)
+	Tags       []string       `json:"tags,omitempty"`        // タグ ("/"区切りで階層化, 例: "work/production")
+	URILabel   string         `json:"uri_label,omitempty"`   // 読み取ったotpauth URIのエスケープされたラベル
+	URIParams  []URIParam     `json:"uri_params,omitempty"`  // 読み取ったotpauth URIのクエリパラメータ (出現順)
+	Icon       []byte         `json:"icon,omitempty"`        // アイコン画像 (PNGまたはSVG)
+	IconType   string         `json:"icon_type,omitempty"`   // アイコンの形式 ("image/png", "image/svg+xml")
+	Notes      string         `json:"notes,omitempty"`       // メモ (暗号化して保存し、otpauth URIには含めない)
+	Pinned     bool           `json:"pinned,omitempty"`      // ピン留め（リストの先頭のセクションに表示する）
+	LastUsedAt time.Time      `json:"last_used_at,omitzero"` // 最後にコードをコピーした日時
+	UseCount   int            `json:"use_count,omitempty"`   // コードをコピーした回数
+	Order      int            `json:"order"`                 // 表示順序
+	CreatedAt  time.Time      `json:"created_at"`            // 登録日時
+	DeletedAt  time.Time      `json:"deleted_at,omitzero"`   // ゴミ箱に移動した日時（ゴミ箱にない場合はゼロ値）
+
+	generator *entryGenerator // コード生成用のキャッシュ（シークレットやパラメータの変更時に再作成する）
+	iconKey   string          // アイコンの内容から求めたキー（アイコンの設定時に更新する）
+}
+
+// entryGenerator はEntryのシークレットとパラメータから作成したGeneratorのキャッシュ
+type entryGenerator struct {
+	key       generatorKey
+	generator *totp.Generator
+}
+
+// generatorKey はGeneratorの作成に使用したEntryのフィールド
+type generatorKey struct {
+	hotp      bool
+	secret    string
+	algorithm totp.Algorithm
+	digits    int
+	period    int
+	t0        int64
+	encoder   string
 }
 
+// generatorMu はEntryのGeneratorキャッシュの参照と更新を保護する
+var generatorMu sync.Mutex
+
 // NewEntry は新しいTOTPエントリを作成する
 func NewEntry(issuer string, account string, secret string) *Entry {
 	return &Entry{
 		ID:        xid.New().String(),
 		Issuer:    issuer,
 		Account:   account,
+		Type:      TypeTOTP,
 		Secret:    secret,
-		Algorithm: algorithmSHA1,
-		Digits:    6,
-		Period:    30,
+		Algorithm: totp.AlgorithmSHA1,
+		Digits:    totp.DefaultDigits,
+		Period:    totp.DefaultPeriod,
 		Order:     0,
 		CreatedAt: time.Now(),
 	}
 }
 
 // ParseOTPAuthURI はotpauth:// URIをパースしてEntryを生成する
-// 形式: otpauth://totp/ISSUER:ACCOUNT?secret=SECRET&issuer=ISSUER&algorithm=SHA1&digits=6&period=30
+// 形式: otpauth://totp/ISSUER:ACCOUNT?secret=SECRET&issuer=ISSUER&algorithm=SHA1&digits=6&period=30&t0=0&tags=TAG1,TAG2
+// HOTPの場合: otpauth://hotp/ISSUER:ACCOUNT?secret=SECRET&counter=0
+// Key URI仕様に従い、issuerパラメータがある場合はラベルの接頭辞より優先する
+// ラベルとクエリパラメータは出現順に元のエンコードのままURILabelとURIParamsに保持し、解釈しないパラメータ（image, color等）も失わない
+// imageパラメータがdata: URIの場合はアイコンとして取り込む（取り込めない場合は解釈しないパラメータとして残す）
 func ParseOTPAuthURI(uri string) (*Entry, error) {
 	u, err := url.Parse(uri)
 	if err != nil {
@@ -52,96 +104,149 @@ func ParseOTPAuthURI(uri string) (*Entry, error) {
 		return nil, ErrInvalidURIScheme
 	}
 
-	if u.Host != "totp" {
+	otpType := u.Host
+	if otpType != TypeTOTP && otpType != TypeHOTP {
 		return nil, ErrNotTOTP
 	}
 
-	// パスからissuerとaccountを抽出
-	// 形式: /ISSUER:ACCOUNT または /ACCOUNT
-	path := strings.TrimPrefix(u.Path, "/")
-	var issuer, account string
-
-	if strings.Contains(path, ":") {
-		parts := strings.SplitN(path, ":", 2)
-		issuer = parts[0]
-		account = parts[1]
-	} else {
-		account = path
+	// ラベルからissuerとaccountを抽出
+	issuer, account, err := parseLabel(u.EscapedPath())
+	if err != nil {
+		return nil, err
 	}
 
-	// クエリパラメータを取得
-	query := u.Query()
+	// クエリパラメータを出現順に取得
+	params, err := parseQueryParams(u.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+	query := queryValues(params)
 
 	secret := query.Get("secret")
 	if secret == "" {
 		return nil, ErrMissingSecret
 	}
 
-	// issuerがパスにない場合、クエリから取得
-	if issuer == "" {
-		issuer = query.Get("issuer")
+	// issuerパラメータはラベルの接頭辞より優先する
+	if q := query.Get("issuer"); q != "" {
+		issuer = q
 	}
 
-	// デフォルト値を設定
-	algorithm := query.Get("algorithm")
-	if algorithm == "" {
-		algorithm = algorithmSHA1
+	entry := &Entry{
+		ID:        xid.New().String(),
+		Issuer:    issuer,
+		Account:   account,
+		Type:      otpType,
+		Secret:    strings.ToUpper(secret), // Base32は大文字
+		Tags:      ParseTags(query.Get("tags")),
+		URILabel:  strings.TrimPrefix(u.EscapedPath(), "/"),
+		URIParams: params,
+		Order:     0,
+		CreatedAt: time.Now(),
 	}
 
-	digits := 6
-	if d := query.Get("digits"); d != "" {
-		if parsed, err := strconv.Atoi(d); err == nil {
-			digits = parsed
+	// コード生成パラメータを設定して検証
+	if err := entry.applyQuery(query); err != nil {
+		return nil, err
+	}
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+
+	// アイコンは任意のため、取り込めない場合もエントリは作成する
+	if image := query.Get("image"); isDataURI(image) && entry.SetIconFromDataURI(image) == nil {
+		entry.markIconParam()
+	}
+	entry.compactURIParams()
+
+	return entry, nil
+}
+
+// applyQuery はクエリパラメータからコード生成パラメータを設定する
+// 省略されたパラメータにはデフォルト値を設定し、数値として解釈できない値はエラーとする
+func (e *Entry) applyQuery(query url.Values) error {
+	e.Algorithm = totp.AlgorithmSHA1
+	if a := query.Get("algorithm"); a != "" {
+		algorithm, err := totp.ParseAlgorithm(a)
+		if err != nil {
+			return err
 		}
+		e.Algorithm = algorithm
 	}
 
-	period := 30
-	if p := query.Get("period"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil {
-			period = parsed
+	e.Encoder = detectEncoder(query.Get("encoder"), e.Issuer)
+
+	e.Digits = totp.DefaultDigits
+	if e.Encoder == totp.EncoderSteam {
+		e.Digits = steamDigits
+	}
+	if d := query.Get("digits"); d != "" {
+		digits, err := strconv.Atoi(d)
+		if err != nil {
+			return totp.ErrInvalidDigits
 		}
+		e.Digits = digits
 	}
 
-	return &Entry{
-		ID:        xid.New().String(),
-		Issuer:    issuer,
-		Account:   account,
-		Secret:    strings.ToUpper(secret), // Base32は大文字
-		Algorithm: strings.ToUpper(algorithm),
-		Digits:    digits,
-		Period:    period,
-		Order:     0,
-		CreatedAt: time.Now(),
-	}, nil
-}
+	// HOTPは更新間隔を使用しないためデフォルト値のままとする
+	e.Period = totp.DefaultPeriod
+	if e.IsHOTP() {
+		if c := query.Get("counter"); c != "" {
+			counter, err := strconv.ParseUint(c, 10, 64)
+			if err != nil {
+				return ErrInvalidCounter
+			}
+			e.Counter = counter
+		}
+		return nil
+	}
 
-// ToOTPAuthURI はEntryをotpauth:// URI形式に変換する
-func (e *Entry) ToOTPAuthURI() string {
-	// ラベルを構築
-	var label string
-	if e.Issuer != "" {
-		label = url.PathEscape(e.Issuer) + ":" + url.PathEscape(e.Account)
-	} else {
-		label = url.PathEscape(e.Account)
+	if p := query.Get("period"); p != "" {
+		period, err := strconv.Atoi(p)
+		if err != nil {
+			return totp.ErrInvalidPeriod
+		}
+		e.Period = period
 	}
 
-	// クエリパラメータを構築
-	params := url.Values{}
-	params.Set("secret", e.Secret)
-	if e.Issuer != "" {
-		params.Set("issuer", e.Issuer)
+	if t := query.Get("t0"); t != "" {
+		t0, err := strconv.ParseInt(t, 10, 64)
+		if err != nil {
+			return ErrInvalidT0
+		}
+		e.T0 = t0
 	}
-	if e.Algorithm != algorithmSHA1 {
-		params.Set("algorithm", e.Algorithm)
+	return nil
+}
+
+// detectEncoder はencoderパラメータまたはissuerからエンコーダー名を決定する
+// encoderパラメータがなく、issuerがSteamの場合はSteamエンコーダーとみなす
+func detectEncoder(encoder, issuer string) string {
+	encoder = strings.ToLower(encoder)
+	if encoder == "" && strings.EqualFold(issuer, "Steam") {
+		encoder = totp.EncoderSteam
 	}
-	if e.Digits != 6 {
-		params.Set("digits", strconv.Itoa(e.Digits))
+	if encoder == totp.EncoderDecimal {
+		encoder = ""
 	}
-	if e.Period != 30 {
-		params.Set("period", strconv.Itoa(e.Period))
+	return encoder
+}
+
+// ToOTPAuthURI はEntryのフィールドからotpauth:// URIを構築する
+// 読み取ったURIのラベルとパラメータは、値が変わっていなければ元の順序とエンコードのまま出力する
+// エンコーダーは他のアプリでも同じコードになるよう明示し、新しく設定したアイコンはQRコードに収まらないため含めない
+func (e *Entry) ToOTPAuthURI() string {
+	otpType := TypeTOTP
+	if e.IsHOTP() {
+		otpType = TypeHOTP
 	}
+	label, labelIssuer := e.uriLabel()
+	return "otpauth://" + otpType + "/" + label + "?" + e.uriQuery(labelIssuer)
+}
 
-	return "otpauth://totp/" + label + "?" + params.Encode()
+// IsHOTP はカウンターベースのエントリかどうかを返す
+func (e *Entry) IsHOTP() bool {
+	return e.Type == TypeHOTP
 }
 
 // DisplayName は表示用の名前を返す
@@ -155,16 +260,141 @@ func (e *Entry) DisplayName() string {
 	return e.Account
 }
 
-// TOTP はEntryから現在のTOTPコードを生成する
-func (e *Entry) TOTP() (string, error) {
-	code, err := totp.Generate(e.Secret, time.Now(), e.Digits, e.Period, e.Algorithm)
+// Params はEntryのコード生成パラメータを検証して返す
+func (e *Entry) Params() (totp.Params, error) {
+	encoder, err := totp.LookupEncoder(e.Encoder)
+	if err != nil {
+		return totp.Params{}, err
+	}
+
+	params := totp.Params{
+		Algorithm: e.Algorithm,
+		Digits:    e.Digits,
+		Period:    e.Period,
+		T0:        e.T0,
+		Encoder:   encoder,
+	}
+
+	validate := params.Validate
+	if e.IsHOTP() {
+		validate = params.ValidateForHOTP
+	}
+	if err := validate(); err != nil {
+		return totp.Params{}, err
+	}
+	return params, nil
+}
+
+// Validate はEntryのコード生成パラメータとシークレットが有効かどうかを検証する
+func (e *Entry) Validate() error {
+	if strings.TrimSpace(e.Secret) == "" {
+		return ErrInvalidSecret
+	}
+	// コード生成と同じ方法でシークレットをデコードして検証する
+	_, err := e.Generator()
+	return err
+}
+
+// ValidateEntries は複数のEntryを検証し、最初に見つかった無効なEntryのエラーを返す
+func ValidateEntries(entries []*Entry) error {
+	for _, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", entry.DisplayName(), err)
+		}
+	}
+	return nil
+}
+
+// Clone はEntryのコピーを返す
+// ストアが保持するエントリを直接変更せず、コピーを編集してからStore.Updateで置き換える場合に使用する
+func (e *Entry) Clone() *Entry {
+	generatorMu.Lock()
+	clone := *e
+	generatorMu.Unlock()
+
+	clone.Tags = slices.Clone(e.Tags)
+	clone.URIParams = slices.Clone(e.URIParams)
+	clone.Icon = slices.Clone(e.Icon)
+	return &clone
+}
+
+// Generator はEntryのコード生成に使用するGeneratorを返す
+// デコード済みの鍵をキャッシュし、シークレットやパラメータが変更された場合のみ再作成する
+func (e *Entry) Generator() (*totp.Generator, error) {
+	// Batchのロールバックはロックを取得してエントリを書き戻すため、フィールドの参照もロック中に行う
+	generatorMu.Lock()
+	defer generatorMu.Unlock()
+
+	key := generatorKey{
+		hotp:      e.IsHOTP(),
+		secret:    e.Secret,
+		algorithm: e.Algorithm,
+		digits:    e.Digits,
+		period:    e.Period,
+		t0:        e.T0,
+		encoder:   e.Encoder,
+	}
+	if e.generator != nil && e.generator.key == key {
+		return e.generator.generator, nil
+	}
+
+	params, err := e.Params()
+	if err != nil {
+		return nil, err
+	}
+	generator, err := totp.NewGenerator(e.Secret, params)
 	if err != nil {
-		return "", ErrInvalidSecret
+		return nil, ErrInvalidSecret
+	}
+	e.generator = &entryGenerator{key: key, generator: generator}
+	return generator, nil
+}
+
+// TOTP はclockの現在時刻でTOTPコードを生成する
+func (e *Entry) TOTP(clock totp.Clock) (string, error) {
+	generator, err := e.Generator()
+	if err != nil {
+		return "", err
+	}
+	return generator.At(clock.Now())
+}
+
+// Snapshot はclockの現在時刻のTOTPコードと有効期間、前後のコードを生成する
+// コードと残り時間を同じ時刻から求める必要がある場合（コピー時のクリア時間など）に使用する
+func (e *Entry) Snapshot(clock totp.Clock) (totp.Snapshot, error) {
+	if e.IsHOTP() {
+		return totp.Snapshot{}, ErrNotTOTPEntry
+	}
+	generator, err := e.Generator()
+	if err != nil {
+		return totp.Snapshot{}, err
+	}
+	return generator.Snapshot(clock.Now())
+}
+
+// HOTP は現在のカウンター値でHOTPコードを生成する（カウンターは進めない）
+func (e *Entry) HOTP() (string, error) {
+	generator, err := e.Generator()
+	if err != nil {
+		return "", err
+	}
+	return generator.AtCounter(e.Counter), nil
+}
+
+// Verify はコードを検証し、一致したステップのオフセットを返す
+// TOTPはclockの現在時刻のタイムステップ、HOTPは次回カウンター値を基準に ±window の範囲を検索する
+func (e *Entry) Verify(code string, window int, clock totp.Clock) (int, error) {
+	generator, err := e.Generator()
+	if err != nil {
+		return 0, err
+	}
+	if e.IsHOTP() {
+		return generator.ValidateCounter(code, e.Counter, window)
 	}
-	return code, nil
+	return generator.Validate(code, clock.Now(), window)
 }
 
-// RemainingSeconds は次のコード更新までの残り秒数を返す
-func (e *Entry) RemainingSeconds() int {
-	return totp.RemainingSeconds(e.Period)
+// RemainingSeconds はclockの現在時刻から次のコード更新までの残り秒数を返す
+func (e *Entry) RemainingSeconds(clock totp.Clock) int {
+	return totp.Params{Period: e.Period, T0: e.T0}.RemainingSeconds(clock.Now())
 }
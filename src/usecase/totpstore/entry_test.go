@@ -1,7 +1,11 @@
 package totpstore
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
+
+	"github.com/nktmys/winticator/src/pkg/totp"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,8 +50,16 @@ func TestParseOTPAuthURI(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "Not TOTP",
-			uri:     "otpauth://hotp/Test:user?secret=ABC",
+			name:    "HOTP URI",
+			uri:     "otpauth://hotp/Test:user?secret=ABCDEFGH&counter=5",
+			issuer:  "Test",
+			account: "user",
+			secret:  "ABCDEFGH",
+			wantErr: false,
+		},
+		{
+			name:    "Unsupported OTP type",
+			uri:     "otpauth://motp/Test:user?secret=ABC",
 			wantErr: true,
 		},
 		{
@@ -97,6 +109,270 @@ func TestEntryToOTPAuthURI(t *testing.T) {
 	assert.Equal(t, entry.Secret, parsed.Secret)
 }
 
+func TestParseOTPAuthURI_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		err  error
+	}{
+		{
+			name: "zero period",
+			uri:  "otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&period=0",
+			err:  totp.ErrInvalidPeriod,
+		},
+		{
+			name: "non-numeric period",
+			uri:  "otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&period=abc",
+			err:  totp.ErrInvalidPeriod,
+		},
+		{
+			name: "too many digits",
+			uri:  "otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&digits=12",
+			err:  totp.ErrInvalidDigits,
+		},
+		{
+			name: "non-numeric digits",
+			uri:  "otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&digits=six",
+			err:  totp.ErrInvalidDigits,
+		},
+		{
+			name: "unknown algorithm",
+			uri:  "otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&algorithm=SHA3",
+			err:  totp.ErrUnsupportedAlgorithm,
+		},
+		{
+			name: "invalid counter",
+			uri:  "otpauth://hotp/Test:user?secret=JBSWY3DPEHPK3PXP&counter=-1",
+			err:  ErrInvalidCounter,
+		},
+		{
+			name: "non-numeric t0",
+			uri:  "otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&t0=yesterday",
+			err:  ErrInvalidT0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOTPAuthURI(tt.uri)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestParseOTPAuthURI_LowercaseAlgorithm(t *testing.T) {
+	entry, err := ParseOTPAuthURI("otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&algorithm=sha256")
+	require.NoError(t, err)
+	assert.Equal(t, totp.AlgorithmSHA256, entry.Algorithm)
+}
+
+func TestValidateEntries(t *testing.T) {
+	valid := NewEntry("Google", "user", "JBSWY3DPEHPK3PXP")
+	invalid := NewEntry("Broken", "user", "JBSWY3DPEHPK3PXP")
+	invalid.Period = 0
+
+	require.NoError(t, ValidateEntries([]*Entry{valid}))
+
+	err := ValidateEntries([]*Entry{valid, invalid})
+	require.ErrorIs(t, err, totp.ErrInvalidPeriod)
+	assert.Contains(t, err.Error(), "Broken: user")
+}
+
+func TestEntryTOTP_InvalidParams(t *testing.T) {
+	entry := NewEntry("Test", "user", "JBSWY3DPEHPK3PXP")
+	entry.Period = 0
+
+	// ゼロ除算せずにエラーを返すこと
+	_, err := entry.TOTP(totp.SystemClock)
+	assert.ErrorIs(t, err, totp.ErrInvalidPeriod)
+}
+
+func TestParseOTPAuthURI_HOTP(t *testing.T) {
+	entry, err := ParseOTPAuthURI("otpauth://hotp/Test:user?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Test&counter=42")
+	require.NoError(t, err)
+	assert.Equal(t, TypeHOTP, entry.Type)
+	assert.True(t, entry.IsHOTP())
+	assert.Equal(t, uint64(42), entry.Counter)
+
+	entry, err = ParseOTPAuthURI("otpauth://totp/Test:user?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	require.NoError(t, err)
+	assert.Equal(t, TypeTOTP, entry.Type)
+	assert.False(t, entry.IsHOTP())
+}
+
+func TestEntryToOTPAuthURI_HOTP(t *testing.T) {
+	entry := &Entry{
+		Issuer:    "VPN",
+		Account:   "user",
+		Type:      TypeHOTP,
+		Secret:    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Algorithm: "SHA1",
+		Digits:    6,
+		Period:    30,
+		Counter:   7,
+	}
+
+	uri := entry.ToOTPAuthURI()
+	assert.Contains(t, uri, "otpauth://hotp/")
+	assert.Contains(t, uri, "counter=7")
+	assert.NotContains(t, uri, "period=")
+
+	parsed, err := ParseOTPAuthURI(uri)
+	require.NoError(t, err)
+	assert.Equal(t, TypeHOTP, parsed.Type)
+	assert.Equal(t, uint64(7), parsed.Counter)
+}
+
+func TestEntryHOTP(t *testing.T) {
+	// RFC 4226 テストベクター（カウンター=1）
+	entry := &Entry{
+		Type:      TypeHOTP,
+		Secret:    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Algorithm: "SHA1",
+		Digits:    6,
+		Counter:   1,
+	}
+
+	code, err := entry.HOTP()
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestEntryVerify(t *testing.T) {
+	entry := &Entry{
+		Type:      TypeTOTP,
+		Secret:    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Algorithm: "SHA1",
+		Digits:    6,
+		Period:    30,
+	}
+
+	clock := fixedClock(59)
+
+	// RFC 6238 テストベクター: T=59 → 287082 (オフセット0)
+	offset, err := entry.Verify("287082", 2, clock)
+	require.NoError(t, err)
+	assert.Equal(t, 0, offset)
+
+	// 1ステップ前のコードはオフセット-1で一致する（壁時計を読まず、同じ固定時刻から求める）
+	code, err := totp.Generate(entry.Secret, clock.Now().Add(-30*time.Second), totp.DefaultParams())
+	require.NoError(t, err)
+	offset, err = entry.Verify(code, 2, clock)
+	require.NoError(t, err)
+	assert.Equal(t, -1, offset)
+
+	entry.Secret = "invalid!@#$"
+	_, err = entry.Verify(code, 2, clock)
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestEntryVerify_HOTP(t *testing.T) {
+	// RFC 4226 テストベクター: カウンター=3 → 969429
+	entry := &Entry{
+		Type:      TypeHOTP,
+		Secret:    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Algorithm: "SHA1",
+		Digits:    6,
+		Counter:   1,
+	}
+
+	// HOTPは時刻を使用しないが、結果が実行時刻に依存しないよう固定した時刻を渡す
+	clock := fixedClock(59)
+	offset, err := entry.Verify("969429", 3, clock)
+	require.NoError(t, err)
+	assert.Equal(t, 2, offset)
+
+	_, err = entry.Verify("969429", 1, clock)
+	assert.ErrorIs(t, err, totp.ErrCodeMismatch)
+}
+
+func TestParseOTPAuthURI_Encoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		encoder string
+		digits  int
+	}{
+		{
+			name:    "encoder parameter",
+			uri:     "otpauth://totp/Valve:user?secret=JBSWY3DPEHPK3PXP&encoder=steam",
+			encoder: totp.EncoderSteam,
+			digits:  5,
+		},
+		{
+			name:    "Steam issuer hint",
+			uri:     "otpauth://totp/Steam:user?secret=JBSWY3DPEHPK3PXP&issuer=Steam",
+			encoder: totp.EncoderSteam,
+			digits:  5,
+		},
+		{
+			name:    "explicit decimal encoder overrides issuer hint",
+			uri:     "otpauth://totp/Steam:user?secret=JBSWY3DPEHPK3PXP&encoder=decimal",
+			encoder: "",
+			digits:  6,
+		},
+		{
+			name:    "no hint",
+			uri:     "otpauth://totp/Google:user?secret=JBSWY3DPEHPK3PXP",
+			encoder: "",
+			digits:  6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := ParseOTPAuthURI(tt.uri)
+			require.NoError(t, err)
+			assert.Equal(t, tt.encoder, entry.Encoder)
+			assert.Equal(t, tt.digits, entry.Digits)
+		})
+	}
+
+	_, err := ParseOTPAuthURI("otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&encoder=unknown")
+	assert.ErrorIs(t, err, totp.ErrUnknownEncoder)
+}
+
+func TestEntrySteamCode(t *testing.T) {
+	entry, err := ParseOTPAuthURI("otpauth://totp/Steam:user?secret=JBSWY3DPEHPK3PXP&issuer=Steam")
+	require.NoError(t, err)
+
+	code, err := entry.TOTP(totp.SystemClock)
+	require.NoError(t, err)
+	assert.Len(t, code, 5)
+
+	// URIへの変換と再パースでエンコーダーが保持されること
+	uri := entry.ToOTPAuthURI()
+	assert.Contains(t, uri, "encoder=steam")
+	parsed, err := ParseOTPAuthURI(uri)
+	require.NoError(t, err)
+	assert.Equal(t, totp.EncoderSteam, parsed.Encoder)
+	assert.Equal(t, 5, parsed.Digits)
+}
+
+func TestEntryJSONRoundTrip(t *testing.T) {
+	// バックアップのエクスポート/インポートはJSONで行われるため、全フィールドが保持されること
+	entry := &Entry{
+		ID:        "test-id",
+		Issuer:    "Steam",
+		Account:   "user",
+		Type:      TypeTOTP,
+		Secret:    "JBSWY3DPEHPK3PXP",
+		Algorithm: "SHA1",
+		Digits:    5,
+		Period:    30,
+		T0:        1000,
+		Encoder:   totp.EncoderSteam,
+		CreatedAt: time.Unix(1700000000, 0).UTC(),
+	}
+
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	var decoded Entry
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *entry, decoded)
+}
+
 func TestNewEntry(t *testing.T) {
 	entry := NewEntry("GitHub", "myaccount", "SECRETKEY")
 
@@ -104,7 +380,8 @@ func TestNewEntry(t *testing.T) {
 	assert.Equal(t, "GitHub", entry.Issuer)
 	assert.Equal(t, "myaccount", entry.Account)
 	assert.Equal(t, "SECRETKEY", entry.Secret)
-	assert.Equal(t, "SHA1", entry.Algorithm)
+	assert.Equal(t, TypeTOTP, entry.Type)
+	assert.Equal(t, totp.AlgorithmSHA1, entry.Algorithm)
 	assert.Equal(t, 6, entry.Digits)
 	assert.Equal(t, 30, entry.Period)
 	assert.False(t, entry.CreatedAt.IsZero())
@@ -126,3 +403,295 @@ func TestDisplayName(t *testing.T) {
 		assert.Equal(t, tt.expected, entry.DisplayName())
 	}
 }
+
+// fixedClock はテスト用にunix秒で固定されたClockを返す
+func fixedClock(unix int64) totp.Clock {
+	return totp.ClockFunc(func() time.Time { return time.Unix(unix, 0) })
+}
+
+func TestEntryTOTP_Clock(t *testing.T) {
+	entry := NewEntry("Test", "user", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	// RFC 6238 テストベクター
+	code, err := entry.TOTP(fixedClock(59))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	// オフセットで時刻を補正したClockでも同じコードになること
+	code, err = entry.TOTP(totp.WithOffset(fixedClock(9), 50*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestEntryRemainingSeconds_Boundary(t *testing.T) {
+	entry := NewEntry("Test", "user", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	assert.Equal(t, 1, entry.RemainingSeconds(fixedClock(59)))
+	assert.Equal(t, 30, entry.RemainingSeconds(fixedClock(60)))
+
+	entry.Period = 60
+	assert.Equal(t, 60, entry.RemainingSeconds(fixedClock(120)))
+	assert.Equal(t, 1, entry.RemainingSeconds(fixedClock(179)))
+}
+
+func TestEntryGenerator_Cache(t *testing.T) {
+	entry := NewEntry("Test", "user", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	first, err := entry.Generator()
+	require.NoError(t, err)
+	second, err := entry.Generator()
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// パラメータを変更するとGeneratorが再作成されること
+	entry.Digits = 8
+	code, err := entry.TOTP(fixedClock(59))
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", code)
+
+	// シークレットを無効な値に変更するとエラーになること
+	entry.Secret = "invalid!@#$"
+	_, err = entry.TOTP(fixedClock(59))
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestEntryClone(t *testing.T) {
+	entry := NewEntry("Test", "user", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	entry.SetTags([]string{"work"})
+	_, err := entry.Generator()
+	require.NoError(t, err)
+
+	// コピーを変更しても元のエントリは変更されないこと
+	clone := entry.Clone()
+	clone.Issuer = "Changed"
+	clone.Tags[0] = "home"
+	clone.Digits = 8
+
+	assert.Equal(t, "Test", entry.Issuer)
+	assert.Equal(t, []string{"work"}, entry.Tags)
+	code, err := entry.TOTP(fixedClock(59))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+	code, err = clone.TOTP(fixedClock(59))
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", code)
+}
+
+func BenchmarkEntryTOTP(b *testing.B) {
+	entry := NewEntry("Test", "user", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	clock := fixedClock(1234567890)
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = entry.TOTP(clock)
+	}
+}
+
+func TestEntrySnapshot(t *testing.T) {
+	entry := NewEntry("Test", "user", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	snapshot, err := entry.Snapshot(fixedClock(59))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", snapshot.Code)
+	assert.Equal(t, "359152", snapshot.Next)
+	assert.Equal(t, entry.RemainingSeconds(fixedClock(59)), snapshot.RemainingSeconds())
+
+	entry.Type = TypeHOTP
+	_, err = entry.Snapshot(fixedClock(59))
+	assert.ErrorIs(t, err, ErrNotTOTPEntry)
+}
+
+func TestEntryT0(t *testing.T) {
+	entry, err := ParseOTPAuthURI("otpauth://totp/Test:user?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&t0=1000")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), entry.T0)
+
+	// T0からの経過時間でコードと残り時間を計算する
+	code, err := entry.TOTP(fixedClock(1059))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+	assert.Equal(t, 1, entry.RemainingSeconds(fixedClock(1059)))
+
+	// URIの往復でT0が保持されること
+	uri := entry.ToOTPAuthURI()
+	assert.Contains(t, uri, "t0=1000")
+	parsed, err := ParseOTPAuthURI(uri)
+	require.NoError(t, err)
+	assert.Equal(t, entry.T0, parsed.T0)
+
+	// T0が0の場合はURIに含めない
+	entry.T0 = 0
+	assert.NotContains(t, entry.ToOTPAuthURI(), "t0=")
+}
+
+func TestParseOTPAuthURI_ExtraParams(t *testing.T) {
+	uri := "otpauth://totp/ACME%20Co:john.doe%40example.com?issuer=ACME%20Co&secret=JBSWY3DPEHPK3PXP&image=https://example.com/logo.png&algorithm=SHA1&digits=6&period=30&color=ff0000&x-vendor=a%2Bb"
+	entry, err := ParseOTPAuthURI(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "ACME Co", entry.Issuer)
+	assert.Equal(t, "john.doe@example.com", entry.Account)
+
+	// パラメータは出現順に元のエンコードのまま保持し、シークレットは二重に保存しない
+	assert.Equal(t, []URIParam{
+		{Key: "issuer", Raw: "issuer=ACME%20Co"},
+		{Key: "secret"},
+		{Key: "image", Value: "https://example.com/logo.png", Raw: "image=https://example.com/logo.png"},
+		{Key: "algorithm", Raw: "algorithm=SHA1"},
+		{Key: "digits", Raw: "digits=6"},
+		{Key: "period", Raw: "period=30"},
+		{Key: "color", Value: "ff0000", Raw: "color=ff0000"},
+		{Key: "x-vendor", Value: "a+b", Raw: "x-vendor=a%2Bb"},
+	}, entry.URIParams)
+
+	// 変更していなければ元のURIをそのまま出力する
+	assert.Equal(t, uri, entry.ToOTPAuthURI())
+
+	// JSONの往復後も同じURIを出力する
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"raw":"secret=`)
+	var decoded Entry
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, uri, decoded.ToOTPAuthURI())
+
+	// 編集したフィールドは同じ位置に現在の値を出力し、元のURIになかったパラメータは末尾に付加する
+	entry.Account = "jane@example.com"
+	entry.Digits = 8
+	entry.T0 = 100
+	assert.Equal(t, "otpauth://totp/ACME%20Co:jane@example.com?issuer=ACME%20Co&secret=JBSWY3DPEHPK3PXP&image=https://example.com/logo.png&algorithm=SHA1&digits=8&period=30&color=ff0000&x-vendor=a%2Bb&t0=100",
+		entry.ToOTPAuthURI())
+
+	parsed, err := ParseOTPAuthURI(entry.ToOTPAuthURI())
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", parsed.Account)
+	assert.Equal(t, 8, parsed.Digits)
+	assert.Equal(t, int64(100), parsed.T0)
+}
+
+func TestToOTPAuthURI_Faithful(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+	}{
+		{name: "lowercase secret", uri: "otpauth://totp/Example:alice@google.com?secret=jbswy3dpehpk3pxp&issuer=Example"},
+		{name: "encoded separator", uri: "otpauth://totp/Example%3Aalice@google.com?secret=JBSWY3DPEHPK3PXP"},
+		{name: "issuer mismatch", uri: "otpauth://totp/Old:user?secret=JBSWY3DPEHPK3PXP&issuer=New"},
+		{name: "plus as space", uri: "otpauth://totp/ACME+Co:user?issuer=ACME+Co&secret=JBSWY3DPEHPK3PXP&tags=work,home"},
+		{name: "hotp", uri: "otpauth://hotp/Example:alice?counter=5&secret=JBSWY3DPEHPK3PXP&algorithm=sha256"},
+		{name: "decimal encoder", uri: "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&encoder=decimal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := ParseOTPAuthURI(tt.uri)
+			require.NoError(t, err)
+			assert.Equal(t, tt.uri, entry.ToOTPAuthURI())
+		})
+	}
+
+	// 変更したフィールドは現在の値を出力する
+	entry, err := ParseOTPAuthURI("otpauth://totp/Old:user?secret=JBSWY3DPEHPK3PXP&issuer=New")
+	require.NoError(t, err)
+	entry.Issuer = "Other"
+	assert.Equal(t, "otpauth://totp/Other:user?secret=JBSWY3DPEHPK3PXP&issuer=Other", entry.ToOTPAuthURI())
+
+	entry, err = ParseOTPAuthURI("otpauth://hotp/Example:alice?counter=5&secret=JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	entry.Counter = 6
+	entry.Secret = "GEZDGNBVGY3TQOJQ"
+	assert.Equal(t, "otpauth://hotp/Example:alice?counter=6&secret=GEZDGNBVGY3TQOJQ", entry.ToOTPAuthURI())
+
+	// 削除したタグはパラメータごと出力しない
+	entry, err = ParseOTPAuthURI("otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&tags=work&color=red")
+	require.NoError(t, err)
+	entry.SetTags(nil)
+	assert.Equal(t, "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&color=red", entry.ToOTPAuthURI())
+}
+
+func TestParseOTPAuthURI_IssuerPrecedence(t *testing.T) {
+	// issuerパラメータはラベルの接頭辞より優先する
+	entry, err := ParseOTPAuthURI("otpauth://totp/Old:user?secret=JBSWY3DPEHPK3PXP&issuer=New")
+	require.NoError(t, err)
+	assert.Equal(t, "New", entry.Issuer)
+	assert.Equal(t, "user", entry.Account)
+
+	// issuerパラメータがなければラベルの接頭辞を使用する
+	entry, err = ParseOTPAuthURI("otpauth://totp/Label:user?secret=JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.Equal(t, "Label", entry.Issuer)
+}
+
+func TestParseOTPAuthURI_LabelEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		issuer  string
+		account string
+	}{
+		{
+			name:    "encoded separator",
+			uri:     "otpauth://totp/Example%3Aalice@google.com?secret=JBSWY3DPEHPK3PXP",
+			issuer:  "Example",
+			account: "alice@google.com",
+		},
+		{
+			name:    "space after separator",
+			uri:     "otpauth://totp/Example:%20alice@google.com?secret=JBSWY3DPEHPK3PXP",
+			issuer:  "Example",
+			account: "alice@google.com",
+		},
+		{
+			name:    "encoded colon in issuer",
+			uri:     "otpauth://totp/A%3AB:alice?secret=JBSWY3DPEHPK3PXP",
+			issuer:  "A:B",
+			account: "alice",
+		},
+		{
+			name:    "plus in label is literal",
+			uri:     "otpauth://totp/A+B:alice+work?secret=JBSWY3DPEHPK3PXP",
+			issuer:  "A+B",
+			account: "alice+work",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := ParseOTPAuthURI(tt.uri)
+			require.NoError(t, err)
+			assert.Equal(t, tt.issuer, entry.Issuer)
+			assert.Equal(t, tt.account, entry.Account)
+
+			// 再構築したURIでも同じ値になること
+			parsed, err := ParseOTPAuthURI(entry.ToOTPAuthURI())
+			require.NoError(t, err)
+			assert.Equal(t, tt.issuer, parsed.Issuer)
+			assert.Equal(t, tt.account, parsed.Account)
+		})
+	}
+}
+
+func TestParseOTPAuthURI_InvalidEncoding(t *testing.T) {
+	_, err := ParseOTPAuthURI("otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&image=%ZZ")
+	assert.ErrorIs(t, err, ErrInvalidURIEncoding)
+}
+
+func TestEntryValidate_Secret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"empty", ""},
+		{"blank", "   "},
+		{"invalid character", "JBSWY3DP1"},
+		{"invalid length", "JBSWY3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := NewEntry("Test", "user", tt.secret)
+			assert.ErrorIs(t, entry.Validate(), ErrInvalidSecret)
+		})
+	}
+
+	_, err := ParseOTPAuthURI("otpauth://totp/Test:user?secret=JBSWY3DP1")
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
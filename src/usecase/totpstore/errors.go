@@ -11,15 +11,48 @@ var (
 	// ErrInvalidURIScheme はURIスキームがotpauthでない場合のエラー
 	ErrInvalidURIScheme = errors.New("invalid URI scheme: expected otpauth")
 
-	// ErrNotTOTP はホストがtotpでない場合のエラー
-	ErrNotTOTP = errors.New("not a TOTP URI: expected totp type")
+	// ErrInvalidURIEncoding はURIのパーセントエンコーディングが不正な場合のエラー
+	ErrInvalidURIEncoding = errors.New("invalid percent-encoding in URI")
+
+	// ErrNotTOTP はホストがtotpまたはhotpでない場合のエラー
+	ErrNotTOTP = errors.New("not an OTP URI: expected totp or hotp type")
 
 	// ErrMissingSecret はシークレットが指定されていない場合のエラー
 	ErrMissingSecret = errors.New("missing secret in URI")
 
+	// ErrInvalidCounter はHOTPのカウンター値が無効な場合のエラー
+	ErrInvalidCounter = errors.New("invalid HOTP counter in URI")
+
+	// ErrInvalidT0 はTOTPのカウント開始時刻（t0）が無効な場合のエラー
+	ErrInvalidT0 = errors.New("invalid TOTP start time (t0) in URI")
+
 	// ErrInvalidSecret はシークレットが無効な場合のエラー
 	ErrInvalidSecret = errors.New("invalid Base32 secret")
 
+	// ErrInvalidVault は保存データの形式が不正な場合のエラー
+	ErrInvalidVault = errors.New("invalid vault data")
+
+	// ErrUnsupportedSchema は保存データのスキーマが新しいバージョンのアプリのものである場合のエラー
+	ErrUnsupportedSchema = errors.New("vault data was saved by a newer version of the app")
+
+	// ErrVaultNotFound は指定された保存データファイルが見つからない場合のエラー
+	ErrVaultNotFound = errors.New("vault file not found")
+
+	// ErrVaultUnreadable は保存データを復号・デコードできない場合のエラー
+	ErrVaultUnreadable = errors.New("vault data cannot be decrypted")
+
+	// ErrVaultLocked は読み込めなかった保存データを隔離できず、上書きを防ぐため保存できない場合のエラー
+	ErrVaultLocked = errors.New("vault is locked to protect unreadable data")
+
+	// ErrNotInRecovery は復旧モードでない場合に復旧操作を行った場合のエラー
+	ErrNotInRecovery = errors.New("vault is not in recovery mode")
+
+	// ErrSnapshotsUnsupported は保存先がスナップショットに対応していない場合のエラー
+	ErrSnapshotsUnsupported = errors.New("storage backend does not support snapshots")
+
+	// ErrSnapshotNotFound はスナップショットが見つからない場合のエラー
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+
 	// ErrInvalidMigrationURI はotpauth-migration URIが無効な場合のエラー
 	ErrInvalidMigrationURI = errors.New("invalid otpauth-migration URI")
 
@@ -29,6 +62,33 @@ var (
 	// ErrInvalidMigrationData はmigrationデータが無効な場合のエラー
 	ErrInvalidMigrationData = errors.New("invalid migration data")
 
-	// ErrNoTOTPEntries はmigrationデータにTOTPエントリがない場合のエラー
-	ErrNoTOTPEntries = errors.New("no TOTP entries found in migration data")
+	// ErrUnspecifiedAlgorithm はmigrationデータでアルゴリズムが指定されていない場合の警告
+	ErrUnspecifiedAlgorithm = errors.New("algorithm not specified, assuming SHA1")
+
+	// ErrUnsupportedOTPType はmigrationデータの項目がTOTP/HOTP以外の種別の場合の警告
+	ErrUnsupportedOTPType = errors.New("unsupported OTP type")
+
+	// ErrNoTOTPEntries はmigrationデータにTOTP/HOTPエントリがない場合のエラー
+	ErrNoTOTPEntries = errors.New("no TOTP/HOTP entries found in migration data")
+
+	// ErrUnsupportedMigrationParams はエントリのパラメータをmigrationデータで表せない場合のエラー
+	ErrUnsupportedMigrationParams = errors.New("parameters cannot be represented in migration data")
+
+	// ErrNoMigrationEntries はmigrationデータにエクスポートできるエントリがない場合のエラー
+	ErrNoMigrationEntries = errors.New("no entries can be exported as migration data")
+
+	// ErrNotHOTP はHOTP専用の操作をTOTPエントリに対して行った場合のエラー
+	ErrNotHOTP = errors.New("entry is not a HOTP entry")
+
+	// ErrNotTOTPEntry はTOTP専用の操作をHOTPエントリに対して行った場合のエラー
+	ErrNotTOTPEntry = errors.New("entry is not a TOTP entry")
+
+	// ErrInvalidDataURI はdata: URIの形式が不正な場合のエラー
+	ErrInvalidDataURI = errors.New("invalid data URI")
+
+	// ErrUnsupportedIcon はアイコンの画像形式がPNGまたはSVGでない場合のエラー
+	ErrUnsupportedIcon = errors.New("unsupported icon format: expected PNG or SVG")
+
+	// ErrIconTooLarge はアイコンの画像が大きすぎる場合のエラー
+	ErrIconTooLarge = errors.New("icon image is too large")
 )
@@ -0,0 +1,111 @@
+package totpstore
+
+import (
+	"slices"
+	"sync"
+)
+
+// EventType はStoreの変更イベントの種類
+type EventType int
+
+// Storeの変更イベントの種類
+const (
+	EventAdded     EventType = iota // エントリを追加した
+	EventUpdated                    // エントリを更新した
+	EventDeleted                    // エントリを削除した（ゴミ箱に移動した）
+	EventReordered                  // エントリの順序を変更した
+	EventReloaded                   // エントリ全体を読み込み直した
+	EventPurged                     // ゴミ箱のエントリを完全に削除した
+)
+
+// String はイベントの種類の名前を返す
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventUpdated:
+		return "updated"
+	case EventDeleted:
+		return "deleted"
+	case EventReordered:
+		return "reordered"
+	case EventReloaded:
+		return "reloaded"
+	case EventPurged:
+		return "purged"
+	default:
+		return "unknown"
+	}
+}
+
+// Event はStoreの変更イベント
+type Event struct {
+	Type EventType
+	IDs  []string // 対象のエントリのID（EventReloadedの場合は空）
+}
+
+// Listener はStoreの変更イベントを受け取る関数
+// 変更を行ったゴルーチンからロックを解放した後に呼び出される
+type Listener func(Event)
+
+// subscription は登録されたListener
+type subscription struct {
+	id       int
+	listener Listener
+}
+
+// listeners は登録されたListenerの一覧
+type listeners struct {
+	mu     sync.Mutex
+	nextID int
+	subs   []subscription
+}
+
+// Subscribe はStoreの変更イベントを受け取るListenerを登録し、登録を解除する関数を返す
+func (s *Store) Subscribe(listener Listener) (unsubscribe func()) {
+	s.listeners.mu.Lock()
+	defer s.listeners.mu.Unlock()
+
+	id := s.listeners.nextID
+	s.listeners.nextID++
+	s.listeners.subs = append(s.listeners.subs, subscription{id: id, listener: listener})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.listeners.mu.Lock()
+			defer s.listeners.mu.Unlock()
+			s.listeners.subs = slices.DeleteFunc(s.listeners.subs, func(sub subscription) bool {
+				return sub.id == id
+			})
+		})
+	}
+}
+
+// emit は変更イベントを通知待ちに追加する（ロックは呼び出し側で取得する）
+// 通知はunlockでロックを解放した後に行う
+func (s *Store) emit(eventType EventType, ids ...string) {
+	s.pending = append(s.pending, Event{Type: eventType, IDs: ids})
+}
+
+// unlock はロックを解放し、通知待ちの変更イベントをListenerに通知する
+// Listenerの中からStoreを呼び出せるよう、ロックを解放してから通知する
+func (s *Store) unlock() {
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	s.listeners.mu.Lock()
+	subs := slices.Clone(s.listeners.subs)
+	s.listeners.mu.Unlock()
+
+	for _, event := range pending {
+		for _, sub := range subs {
+			sub.listener(event)
+		}
+	}
+}
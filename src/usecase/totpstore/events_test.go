@@ -0,0 +1,104 @@
+package totpstore
+
+import (
+	"testing"
+
+	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordEvents はStoreの変更イベントを記録するListenerを登録する
+func recordEvents(t *testing.T, store *Store) *[]Event {
+	t.Helper()
+	events := &[]Event{}
+	unsubscribe := store.Subscribe(func(event Event) {
+		*events = append(*events, event)
+	})
+	t.Cleanup(unsubscribe)
+	return events
+}
+
+func TestStore_Subscribe(t *testing.T) {
+	machinekey.ResetCache()
+
+	store := NewWithBackend(NewMemoryBackend())
+	events := recordEvents(t, store)
+	require.NoError(t, store.Load())
+
+	first := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+	second := NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ")
+	require.NoError(t, store.Add(first))
+	require.NoError(t, store.Add(second))
+	require.NoError(t, store.Update(first))
+	require.NoError(t, store.SetPinned(second.ID, true))
+	require.NoError(t, store.RecordUse(first.ID))
+	require.NoError(t, store.Reorder([]string{second.ID, first.ID}))
+	require.NoError(t, store.Delete(first.ID))
+
+	assert.Equal(t, []Event{
+		{Type: EventReloaded},
+		{Type: EventAdded, IDs: []string{first.ID}},
+		{Type: EventAdded, IDs: []string{second.ID}},
+		{Type: EventUpdated, IDs: []string{first.ID}},
+		{Type: EventUpdated, IDs: []string{second.ID}},
+		{Type: EventUpdated, IDs: []string{first.ID}},
+		{Type: EventReordered, IDs: []string{second.ID, first.ID}},
+		{Type: EventDeleted, IDs: []string{first.ID}},
+	}, *events)
+}
+
+func TestStore_Subscribe_NoEventOnFailure(t *testing.T) {
+	store := NewWithBackend(NewMemoryBackend())
+	events := recordEvents(t, store)
+
+	assert.ErrorIs(t, store.Update(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")), ErrEntryNotFound)
+	assert.ErrorIs(t, store.Delete("missing"), ErrEntryNotFound)
+	assert.ErrorIs(t, store.SetPinned("missing", true), ErrEntryNotFound)
+	assert.Empty(t, *events)
+}
+
+func TestStore_Subscribe_AddResolved(t *testing.T) {
+	machinekey.ResetCache()
+
+	store := NewWithBackend(NewMemoryBackend())
+	existing := NewEntry("Google", "user", "JBSWY3DPEHPK3PXP")
+	require.NoError(t, store.Add(existing))
+	events := recordEvents(t, store)
+
+	store.AddResolved(NewEntry("Google", "user", "JBSWY3DPEHPK3PXP"), ResolveSkip)
+	store.AddResolved(NewEntry("Google", "user", "JBSWY3DPEHPK3PXP"), ResolveReplace)
+	kept := NewEntry("Google", "user", "JBSWY3DPEHPK3PXP")
+	store.AddResolved(kept, ResolveKeepBoth)
+
+	assert.Equal(t, []Event{
+		{Type: EventUpdated, IDs: []string{existing.ID}},
+		{Type: EventAdded, IDs: []string{kept.ID}},
+	}, *events)
+}
+
+func TestStore_Subscribe_Unsubscribe(t *testing.T) {
+	store := NewWithBackend(NewMemoryBackend())
+
+	count := 0
+	unsubscribe := store.Subscribe(func(Event) { count++ })
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	unsubscribe()
+	unsubscribe()
+	require.NoError(t, store.Add(NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ")))
+
+	assert.Equal(t, 1, count)
+}
+
+func TestStore_Subscribe_ListenerCanReadStore(t *testing.T) {
+	store := NewWithBackend(NewMemoryBackend())
+
+	var counts []int
+	store.Subscribe(func(Event) {
+		// ロック解放後に通知されるため、Listenerの中からStoreを参照できる
+		counts = append(counts, store.Count())
+	})
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+
+	assert.Equal(t, []int{1}, counts)
+}
@@ -0,0 +1,364 @@
+package totpstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// VaultFileName は保存データファイルの既定のファイル名
+	VaultFileName = "vault.wtvault"
+
+	// snapshotDirName は保存データファイルと同じフォルダに作成するスナップショットのフォルダ名
+	snapshotDirName = "snapshots"
+
+	// quarantineDirName は読み込めなかった保存データを隔離するフォルダ名
+	quarantineDirName = "quarantine"
+
+	// snapshotTimeFormat はスナップショットのファイル名に使用する日時の形式（名前順が日時順になる）
+	snapshotTimeFormat = "20060102T150405.000000000Z"
+
+	// DefaultMaxSnapshots は保持するスナップショットの既定の数
+	DefaultMaxSnapshots = 10
+)
+
+// VaultSnapshot は保存データのスナップショット（暗号化された過去の保存データ）
+type VaultSnapshot struct {
+	ID        string    // スナップショットの識別子
+	CreatedAt time.Time // 作成日時
+	Size      int64     // 保存データのサイズ（バイト）
+}
+
+// SnapshotBackend は過去の保存データのスナップショットを保持できるBackend
+type SnapshotBackend interface {
+	Backend
+	// Snapshots はスナップショットを新しい順に返す
+	Snapshots() ([]VaultSnapshot, error)
+	// LoadSnapshot は指定したスナップショットの保存データを読み込む
+	LoadSnapshot(id string) ([]byte, error)
+	// Snapshot は現在の保存データのスナップショットを作成する
+	Snapshot() error
+}
+
+// SnapshotImporter は別のBackendのスナップショットを取り込めるBackend（保存先の変更時に使用する）
+type SnapshotImporter interface {
+	// ImportSnapshots は指定したBackendのスナップショットを取り込む
+	ImportSnapshots(from SnapshotBackend) error
+}
+
+// FileBackend は指定したパスのファイルに保存データを保存するBackend
+// 保存は一時ファイルへの書き込みと名前の変更で行い、書き込み中に中断しても元のファイルを壊さない
+// 上書きする前の保存データは保存のたびにスナップショットとして保持する
+// 保存データとスナップショットを変更する操作は、同時に呼び出されてもスナップショットの作成と削除が競合しないよう直列化する
+type FileBackend struct {
+	path         string
+	required     bool // 保存データファイルがない場合にエラーにする
+	maxSnapshots int
+	now          func() time.Time
+	mu           sync.Mutex
+}
+
+// NewFileBackend は新しいFileBackendを作成する
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{
+		path:         path,
+		maxSnapshots: DefaultMaxSnapshots,
+		now:          time.Now,
+	}
+}
+
+// SetSnapshotPolicy は保持するスナップショットの数を設定する
+// maxSnapshotsが0以下の場合はスナップショットを作成しない
+func (b *FileBackend) SetSnapshotPolicy(maxSnapshots int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxSnapshots = maxSnapshots
+}
+
+// SetRequired は保存データファイルがない場合に、初回起動として空の保存データを返すのではなくエラーにするかどうかを設定する
+// ユーザーが指定した保存先のファイルやフォルダが見つからない場合に、新しい空の保存データとして扱わないために使用する
+func (b *FileBackend) SetRequired(required bool) {
+	b.required = required
+}
+
+// Path は保存データファイルのパスを返す
+func (b *FileBackend) Path() string {
+	return b.path
+}
+
+// Load は保存データを読み込む
+// 保存データファイルが必須の場合、ファイルがなければErrVaultNotFoundを返す
+func (b *FileBackend) Load() ([]byte, error) {
+	data, err := b.read()
+	if err == nil && data == nil && b.required {
+		return nil, fmt.Errorf("%w: %s", ErrVaultNotFound, b.path)
+	}
+	return data, err
+}
+
+// read は保存データファイルを読み込む（ファイルがない場合はnilを返す）
+func (b *FileBackend) read() ([]byte, error) {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Save は保存データを書き込む（ファイルは所有者のみ読み書きできる権限で作成する）
+// 上書きする前の保存データのスナップショットを作成してから書き込む
+func (b *FileBackend) Save(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o700); err != nil {
+		return err
+	}
+	if err := b.snapshot(); err != nil {
+		return err
+	}
+	return writeFileAtomic(b.path, data)
+}
+
+// Clear は保存データファイルを削除する（スナップショットは残す）
+func (b *FileBackend) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := os.Remove(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Snapshots はスナップショットを新しい順に返す
+func (b *FileBackend) Snapshots() ([]VaultSnapshot, error) {
+	dirEntries, err := os.ReadDir(b.snapshotDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []VaultSnapshot
+	for _, dirEntry := range dirEntries {
+		createdAt, ok := parseSnapshotName(dirEntry.Name())
+		if !ok || dirEntry.IsDir() {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, VaultSnapshot{
+			ID:        dirEntry.Name(),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+
+	slices.SortFunc(snapshots, func(a, b VaultSnapshot) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// LoadSnapshot は指定したスナップショットの保存データを読み込む
+func (b *FileBackend) LoadSnapshot(id string) ([]byte, error) {
+	if _, ok := parseSnapshotName(id); !ok || filepath.Base(id) != id {
+		return nil, ErrSnapshotNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(b.snapshotDir(), id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrSnapshotNotFound
+	}
+	return data, err
+}
+
+// Snapshot は現在の保存データのスナップショットを作成し、古いスナップショットを削除する
+// 保存データがない場合や、最新のスナップショットと同じ内容の場合は何もしない
+func (b *FileBackend) Snapshot() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.snapshot()
+}
+
+// snapshot は現在の保存データのスナップショットを作成する（ロックは呼び出し側で取得する）
+func (b *FileBackend) snapshot() error {
+	if b.maxSnapshots <= 0 {
+		return nil
+	}
+	data, err := b.read()
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	if latest, ok := b.latestSnapshot(); ok && bytes.Equal(latest, data) {
+		return nil
+	}
+
+	if err := os.MkdirAll(b.snapshotDir(), 0o700); err != nil {
+		return err
+	}
+	name := snapshotName(b.now())
+	if err := writeFileAtomic(filepath.Join(b.snapshotDir(), name), data); err != nil {
+		return err
+	}
+	return b.pruneSnapshots()
+}
+
+// ImportSnapshots は指定したBackendのスナップショットを同じ識別子でコピーし、古いスナップショットを削除する
+// 同じ識別子のスナップショットが既にある場合は上書きしない
+func (b *FileBackend) ImportSnapshots(from SnapshotBackend) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.maxSnapshots <= 0 {
+		return nil
+	}
+	snapshots, err := from.Snapshots()
+	if err != nil || len(snapshots) == 0 {
+		return err
+	}
+	if err := os.MkdirAll(b.snapshotDir(), 0o700); err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		if _, ok := parseSnapshotName(snapshot.ID); !ok || filepath.Base(snapshot.ID) != snapshot.ID {
+			continue
+		}
+		path := filepath.Join(b.snapshotDir(), snapshot.ID)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		data, err := from.LoadSnapshot(snapshot.ID)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path, data); err != nil {
+			return err
+		}
+	}
+	return b.pruneSnapshots()
+}
+
+// Quarantine は読み込めなかった保存データを隔離フォルダに保存し、そのパスを返す
+// 同じ内容の保存データを隔離済みの場合は既存のパスを返す
+func (b *FileBackend) Quarantine(data []byte) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dir := filepath.Join(filepath.Dir(b.path), quarantineDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, dirEntry := range dirEntries {
+		path := filepath.Join(dir, dirEntry.Name())
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+			return path, nil
+		}
+	}
+
+	path := filepath.Join(dir, snapshotName(b.now()))
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// latestSnapshot は最新のスナップショットの保存データを返す（スナップショットがない場合はfalse）
+func (b *FileBackend) latestSnapshot() ([]byte, bool) {
+	snapshots, err := b.Snapshots()
+	if err != nil || len(snapshots) == 0 {
+		return nil, false
+	}
+	data, err := b.LoadSnapshot(snapshots[0].ID)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// pruneSnapshots は保持する数を超えた古いスナップショットを削除する
+func (b *FileBackend) pruneSnapshots() error {
+	snapshots, err := b.Snapshots()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots[min(len(snapshots), b.maxSnapshots):] {
+		if err := os.Remove(filepath.Join(b.snapshotDir(), snapshot.ID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotDir はスナップショットを保存するフォルダのパスを返す
+func (b *FileBackend) snapshotDir() string {
+	return filepath.Join(filepath.Dir(b.path), snapshotDirName)
+}
+
+// snapshotName は作成日時からスナップショットのファイル名を作成する
+func snapshotName(t time.Time) string {
+	return strings.TrimSuffix(VaultFileName, filepath.Ext(VaultFileName)) + "-" +
+		t.UTC().Format(snapshotTimeFormat) + filepath.Ext(VaultFileName)
+}
+
+// parseSnapshotName はスナップショットのファイル名から作成日時を取得する
+func parseSnapshotName(name string) (time.Time, bool) {
+	prefix := strings.TrimSuffix(VaultFileName, filepath.Ext(VaultFileName)) + "-"
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, filepath.Ext(VaultFileName)) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), filepath.Ext(VaultFileName))
+	t, err := time.Parse(snapshotTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// writeFileAtomic は同じフォルダの一時ファイルに書き込んでから名前を変更し、ファイルを置き換える
+// 書き込みに失敗した場合は一時ファイルを削除し、元のファイルは変更しない
+func writeFileAtomic(path string, data []byte) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = tmp.Chmod(0o600); err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	// 名前を変更する前にディスクへ書き出す
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,170 @@
+package totpstore
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFileBackend はテスト用に時刻を指定できるFileBackendを作成する
+func newTestFileBackend(t *testing.T, now *time.Time) *FileBackend {
+	t.Helper()
+	backend := NewFileBackend(filepath.Join(t.TempDir(), VaultFileName))
+	backend.now = func() time.Time { return *now }
+	return backend
+}
+
+func TestFileBackend_SaveIsAtomic(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	backend := newTestFileBackend(t, &now)
+
+	require.NoError(t, backend.Save([]byte("first")))
+	require.NoError(t, backend.Save([]byte("second")))
+
+	data, err := backend.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), data)
+
+	// 一時ファイルが残らないこと
+	dirEntries, err := os.ReadDir(filepath.Dir(backend.Path()))
+	require.NoError(t, err)
+	for _, dirEntry := range dirEntries {
+		assert.False(t, strings.HasSuffix(dirEntry.Name(), ".tmp"), dirEntry.Name())
+	}
+}
+
+func TestFileBackend_Snapshots(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	backend := newTestFileBackend(t, &now)
+	backend.SetSnapshotPolicy(3)
+
+	// 初回の保存では上書きするデータがないためスナップショットを作成しない
+	require.NoError(t, backend.Save([]byte("v1")))
+	snapshots, err := backend.Snapshots()
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	// 保存のたびに上書きする前のデータのスナップショットを作成する
+	now = now.Add(time.Second)
+	require.NoError(t, backend.Save([]byte("v2")))
+	now = now.Add(time.Second)
+	require.NoError(t, backend.Save([]byte("v3")))
+
+	snapshots, err = backend.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	data, err := backend.LoadSnapshot(snapshots[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), data)
+
+	// 最新のスナップショットと同じ内容の場合は作成しない
+	now = now.Add(time.Second)
+	require.NoError(t, backend.Snapshot())
+	snapshots, err = backend.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 3)
+	now = now.Add(time.Second)
+	require.NoError(t, backend.Save([]byte("v4")))
+
+	// 保持する数を超えた古いスナップショットは削除する
+	for _, v := range []string{"v5", "v6"} {
+		now = now.Add(time.Second)
+		require.NoError(t, backend.Save([]byte(v)))
+	}
+	snapshots, err = backend.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 3)
+
+	var contents []string
+	for _, snapshot := range snapshots {
+		data, err := backend.LoadSnapshot(snapshot.ID)
+		require.NoError(t, err)
+		contents = append(contents, string(data))
+	}
+	assert.Equal(t, []string{"v5", "v4", "v3"}, contents)
+	assert.True(t, snapshots[0].CreatedAt.After(snapshots[1].CreatedAt))
+}
+
+func TestFileBackend_ConcurrentSaves(t *testing.T) {
+	backend := NewFileBackend(filepath.Join(t.TempDir(), VaultFileName))
+	backend.SetSnapshotPolicy(3)
+	require.NoError(t, backend.Save([]byte("v0")))
+
+	// 同時に保存してもスナップショットの作成と削除が競合しない
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			assert.NoError(t, backend.Save([]byte(strconv.Itoa(i))))
+		})
+	}
+	wg.Wait()
+
+	snapshots, err := backend.Snapshots()
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 3)
+}
+
+func TestFileBackend_LoadSnapshot_NotFound(t *testing.T) {
+	now := time.Now()
+	backend := newTestFileBackend(t, &now)
+
+	_, err := backend.LoadSnapshot(snapshotName(now))
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+
+	// スナップショットのフォルダ外のファイルは読み込まない
+	_, err = backend.LoadSnapshot("../" + VaultFileName)
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+}
+
+func TestStore_RestoreSnapshot(t *testing.T) {
+	machinekey.ResetCache()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	backend := newTestFileBackend(t, &now)
+	store := NewWithBackend(backend)
+	require.NoError(t, store.Load())
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.NoError(t, store.Save())
+
+	// 誤ったインポートでエントリを失った状態を保存する
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, store.Delete(store.GetAll()[0].ID))
+	require.NoError(t, store.Save())
+	assert.Equal(t, 0, store.Count())
+
+	snapshots, err := store.Snapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+
+	entries, err := store.SnapshotEntries(snapshots[0].ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// 復元すると現在のデータもスナップショットとして残る
+	now = now.Add(time.Minute)
+	require.NoError(t, store.RestoreSnapshot(snapshots[0].ID))
+	assert.Equal(t, 1, store.Count())
+
+	snapshots, err = store.Snapshots()
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+
+	loaded := NewWithBackend(backend)
+	require.NoError(t, loaded.Load())
+	assert.Equal(t, "GitHub", loaded.GetAll()[0].Issuer)
+}
+
+func TestStore_Snapshots_Unsupported(t *testing.T) {
+	store := NewWithBackend(NewMemoryBackend())
+	_, err := store.Snapshots()
+	assert.ErrorIs(t, err, ErrSnapshotsUnsupported)
+	assert.ErrorIs(t, store.RestoreSnapshot("id"), ErrSnapshotsUnsupported)
+}
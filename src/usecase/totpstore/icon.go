@@ -0,0 +1,162 @@
+package totpstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// アイコンの形式
+const (
+	IconTypePNG = "image/png"     // PNG画像
+	IconTypeSVG = "image/svg+xml" // SVG画像
+)
+
+// maxIconSize はアイコンとして保存できる最大バイト数
+// アイコンは暗号化されたデータに含まれるため、保存データが肥大化しないよう制限する
+const maxIconSize = 256 * 1024
+
+// pngSignature はPNGファイルの先頭8バイト
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// HasIcon はエントリにアイコンが設定されているかどうかを返す
+func (e *Entry) HasIcon() bool {
+	return len(e.Icon) > 0
+}
+
+// SetIcon はPNGまたはSVGの画像データをアイコンとして設定する
+// 形式はデータの内容から判定し、対応していない形式や大きすぎるデータはエラーとする
+func (e *Entry) SetIcon(data []byte) error {
+	iconType, err := detectIconType(data)
+	if err != nil {
+		return err
+	}
+	e.Icon = bytes.Clone(data)
+	e.IconType = iconType
+	e.iconKey = iconKey(e.Icon)
+	e.dropIconParams()
+	return nil
+}
+
+// ClearIcon はアイコンを削除する
+func (e *Entry) ClearIcon() {
+	e.Icon = nil
+	e.IconType = ""
+	e.iconKey = ""
+	e.dropIconParams()
+}
+
+// IconKey はアイコンの内容から求めたキーを返す（アイコンがない場合は空文字列）
+// 内容が同じアイコンは同じキーになるため、画像を比較せずに描画用のリソースをキャッシュできる
+func (e *Entry) IconKey() string {
+	if !e.HasIcon() {
+		return ""
+	}
+	if e.iconKey == "" {
+		return iconKey(e.Icon)
+	}
+	return e.iconKey
+}
+
+// iconKey はアイコンの画像データのハッシュ値からキーを求める
+func iconKey(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// dropIconParams はアイコンとして取り込んだimageパラメータを元のURIのパラメータ列から削除する
+// アイコンを変更した場合は、元のURIのアイコンを出力しない
+func (e *Entry) dropIconParams() {
+	e.URIParams = slices.DeleteFunc(e.URIParams, func(p URIParam) bool {
+		return p.Icon
+	})
+	if len(e.URIParams) == 0 {
+		e.URIParams = nil
+	}
+}
+
+// markIconParam は元のURIのパラメータ列から、アイコンとして取り込んだdata: URIのimageパラメータに印を付ける
+func (e *Entry) markIconParam() {
+	for i, p := range e.URIParams {
+		if p.Key == "image" && isDataURI(p.Value) {
+			e.URIParams[i].Icon = true
+			return
+		}
+	}
+}
+
+// iconDataURI はアイコンをdata: URI形式で返す
+func (e *Entry) iconDataURI() string {
+	return "data:" + e.IconType + ";base64," + base64.StdEncoding.EncodeToString(e.Icon)
+}
+
+// isDataURI はdata: URI形式の文字列かどうかを返す
+func isDataURI(s string) bool {
+	return strings.HasPrefix(strings.ToLower(s), "data:")
+}
+
+// SetIconFromDataURI はdata: URI形式の画像をアイコンとして設定する
+func (e *Entry) SetIconFromDataURI(uri string) error {
+	data, err := decodeDataURI(uri)
+	if err != nil {
+		return err
+	}
+	return e.SetIcon(data)
+}
+
+// detectIconType は画像データの内容からアイコンの形式を判定する
+func detectIconType(data []byte) (string, error) {
+	if len(data) > maxIconSize {
+		return "", ErrIconTooLarge
+	}
+	if bytes.HasPrefix(data, pngSignature) {
+		return IconTypePNG, nil
+	}
+
+	// SVGはXML宣言やコメントの後にsvg要素が現れる
+	head := bytes.ToLower(data[:min(len(data), 1024)])
+	if bytes.Contains(head, []byte("<svg")) {
+		return IconTypeSVG, nil
+	}
+	return "", ErrUnsupportedIcon
+}
+
+// decodeDataURI はdata: URI（RFC 2397）のデータ部分をデコードする
+// 形式: data:[<mediatype>][;base64],<data>
+func decodeDataURI(uri string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		rest, ok = strings.CutPrefix(uri, "DATA:")
+	}
+	if !ok {
+		return nil, ErrInvalidDataURI
+	}
+
+	header, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, ErrInvalidDataURI
+	}
+
+	if strings.HasSuffix(strings.ToLower(header), ";base64") {
+		// クエリパラメータのデコードで"+"が空白に変換されている場合に備える
+		payload = strings.ReplaceAll(payload, " ", "+")
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+		}
+		if err != nil {
+			return nil, ErrInvalidDataURI
+		}
+		return data, nil
+	}
+
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, ErrInvalidDataURI
+	}
+	return []byte(data), nil
+}
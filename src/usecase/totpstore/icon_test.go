@@ -0,0 +1,157 @@
+package totpstore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPNG はテスト用の1x1ピクセルのPNG画像を生成する
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))))
+	return buf.Bytes()
+}
+
+const testSVG = `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="1" height="1"/>`
+
+func TestEntrySetIcon(t *testing.T) {
+	entry := NewEntry("Test", "user", "JBSWY3DPEHPK3PXP")
+	assert.False(t, entry.HasIcon())
+
+	require.NoError(t, entry.SetIcon(testPNG(t)))
+	assert.True(t, entry.HasIcon())
+	assert.Equal(t, IconTypePNG, entry.IconType)
+
+	require.NoError(t, entry.SetIcon([]byte(testSVG)))
+	assert.Equal(t, IconTypeSVG, entry.IconType)
+
+	entry.ClearIcon()
+	assert.False(t, entry.HasIcon())
+	assert.Empty(t, entry.IconType)
+}
+
+func TestEntryIconKey(t *testing.T) {
+	entry := NewEntry("Test", "user", "JBSWY3DPEHPK3PXP")
+	assert.Empty(t, entry.IconKey())
+
+	require.NoError(t, entry.SetIcon(testPNG(t)))
+	pngKey := entry.IconKey()
+	assert.NotEmpty(t, pngKey)
+
+	// 同じ内容のアイコンは同じキーになり、内容が変わるとキーも変わる
+	clone := entry.Clone()
+	assert.Equal(t, pngKey, clone.IconKey())
+	require.NoError(t, clone.SetIcon([]byte(testSVG)))
+	assert.NotEqual(t, pngKey, clone.IconKey())
+	assert.Equal(t, pngKey, entry.IconKey())
+
+	// 保存データから読み込んだエントリも同じキーになる
+	data, err := EncodeVault([]*Entry{entry})
+	require.NoError(t, err)
+	decoded, err := DecodeVault(data)
+	require.NoError(t, err)
+	assert.Equal(t, pngKey, decoded[0].IconKey())
+
+	entry.ClearIcon()
+	assert.Empty(t, entry.IconKey())
+}
+
+func TestEntrySetIcon_Invalid(t *testing.T) {
+	entry := NewEntry("Test", "user", "JBSWY3DPEHPK3PXP")
+
+	err := entry.SetIcon([]byte("GIF89a..."))
+	require.ErrorIs(t, err, ErrUnsupportedIcon)
+
+	large := append(testPNG(t), make([]byte, maxIconSize)...)
+	err = entry.SetIcon(large)
+	require.ErrorIs(t, err, ErrIconTooLarge)
+
+	assert.False(t, entry.HasIcon())
+}
+
+func TestEntrySetIconFromDataURI(t *testing.T) {
+	pngData := testPNG(t)
+
+	tests := []struct {
+		name     string
+		uri      string
+		iconType string
+		data     []byte
+	}{
+		{
+			name:     "base64 PNG",
+			uri:      "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData),
+			iconType: IconTypePNG,
+			data:     pngData,
+		},
+		{
+			name:     "percent-encoded SVG",
+			uri:      "data:image/svg+xml," + url.PathEscape(testSVG),
+			iconType: IconTypeSVG,
+			data:     []byte(testSVG),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := NewEntry("Test", "user", "JBSWY3DPEHPK3PXP")
+			require.NoError(t, entry.SetIconFromDataURI(tt.uri))
+			assert.Equal(t, tt.iconType, entry.IconType)
+			assert.Equal(t, tt.data, entry.Icon)
+		})
+	}
+
+	entry := NewEntry("Test", "user", "JBSWY3DPEHPK3PXP")
+	require.ErrorIs(t, entry.SetIconFromDataURI("https://example.com/logo.png"), ErrInvalidDataURI)
+	require.ErrorIs(t, entry.SetIconFromDataURI("data:image/png;base64"), ErrInvalidDataURI)
+	require.ErrorIs(t, entry.SetIconFromDataURI("data:image/png;base64,!!!"), ErrInvalidDataURI)
+}
+
+func TestParseOTPAuthURI_ImageDataURI(t *testing.T) {
+	pngData := testPNG(t)
+	image := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData)
+	uri := "otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&image=" + url.QueryEscape(image)
+
+	entry, err := ParseOTPAuthURI(uri)
+	require.NoError(t, err)
+	assert.Equal(t, IconTypePNG, entry.IconType)
+	assert.Equal(t, pngData, entry.Icon)
+
+	// アイコンとして取り込んだ画像は二重に保存せず、変更していなければ元のURIのまま出力する
+	assert.Equal(t, []URIParam{{Key: "secret"}, {Key: "image", Icon: true}}, entry.URIParams)
+	assert.Equal(t, uri, entry.ToOTPAuthURI())
+
+	// アイコンを変更した場合は古い画像も新しい画像も出力しない
+	require.NoError(t, entry.SetIcon([]byte(testSVG)))
+	assert.NotContains(t, entry.ToOTPAuthURI(), "image=")
+	assert.Empty(t, entry.URIParams[1:])
+
+	// バックアップ（JSON）でもアイコンが保持される
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	var decoded Entry
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, entry.Icon, decoded.Icon)
+	assert.Equal(t, entry.IconType, decoded.IconType)
+}
+
+func TestParseOTPAuthURI_ImageURL(t *testing.T) {
+	// data: URI以外の画像URLは取得せず、パラメータとしてのみ保持する
+	entry, err := ParseOTPAuthURI("otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&image=https%3A%2F%2Fexample.com%2Flogo.png")
+	require.NoError(t, err)
+	assert.False(t, entry.HasIcon())
+	assert.Equal(t, URIParam{
+		Key:   "image",
+		Value: "https://example.com/logo.png",
+		Raw:   "image=https%3A%2F%2Fexample.com%2Flogo.png",
+	}, entry.URIParams[1])
+}
@@ -3,73 +3,233 @@ package totpstore
 import (
 	"encoding/base32"
 	"encoding/base64"
+	"fmt"
+	"math"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/nktmys/winticator/src/usecase/totpstore/migration"
 	"github.com/rs/xid"
 	"google.golang.org/protobuf/proto"
 )
 
+// MigrationBatchSize は1つのotpauth-migration URI（QRコード）に含めるエントリの最大数
+// QRコードとして読み取れる大きさに収まるよう制限する
+const MigrationBatchSize = 10
+
+// ImportWarning はインポート・エクスポート時に項目ごとに検出された警告を表す
+type ImportWarning struct {
+	Name    string // 項目の表示名
+	Skipped bool   // 項目を取り込まなかった場合はtrue
+	Err     error  // 警告の内容
+}
+
+// Error は警告を文字列で返す
+func (w ImportWarning) Error() string {
+	return w.Name + ": " + w.Err.Error()
+}
+
+// Unwrap は警告の元のエラーを返す
+func (w ImportWarning) Unwrap() error {
+	return w.Err
+}
+
 // ParseOTPAuthMigrationURI はotpauth-migration:// URIをパースして複数のEntryを生成する
 // 形式: otpauth-migration://offline?data=BASE64_ENCODED_PROTOBUF
 func ParseOTPAuthMigrationURI(uri string) ([]*Entry, error) {
+	entries, _, err := ParseOTPAuthMigrationURIWithWarnings(uri)
+	return entries, err
+}
+
+// ParseOTPAuthMigrationURIWithWarnings はotpauth-migration:// URIをパースし、
+// 生成したEntryと項目ごとの警告（アルゴリズムの推定や取り込まなかった項目）を返す
+// 取り込めるエントリがない場合もErrNoTOTPEntriesとともに警告を返す
+func ParseOTPAuthMigrationURIWithWarnings(uri string) ([]*Entry, []ImportWarning, error) {
 	u, err := url.Parse(uri)
 	if err != nil {
-		return nil, ErrInvalidMigrationURI
+		return nil, nil, ErrInvalidMigrationURI
 	}
 
 	if u.Scheme != "otpauth-migration" {
-		return nil, ErrInvalidMigrationURI
+		return nil, nil, ErrInvalidMigrationURI
 	}
 
 	// dataパラメータを取得
 	data := u.Query().Get("data")
 	if data == "" {
-		return nil, ErrMissingMigrationData
+		return nil, nil, ErrMissingMigrationData
 	}
 
 	// Base64デコード
 	decoded, err := base64.StdEncoding.DecodeString(data)
 	if err != nil {
-		return nil, ErrInvalidMigrationData
+		return nil, nil, ErrInvalidMigrationData
 	}
 
 	// Protobufデコード
 	payload := &migration.MigrationPayload{}
 	if err := proto.Unmarshal(decoded, payload); err != nil {
-		return nil, ErrInvalidMigrationData
+		return nil, nil, ErrInvalidMigrationData
 	}
 
-	// OtpParametersをEntryに変換（TOTPのみ）
+	// OtpParametersをEntryに変換（TOTP/HOTPのみ）
 	var entries []*Entry
+	var warnings []ImportWarning
 	for _, otp := range payload.GetOtpParameters() {
-		if otp.GetType() != migration.MigrationPayload_TOTP {
-			continue
-		}
-
 		issuer, account := parseMigrationName(otp.GetName(), otp.GetIssuer())
 		secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(otp.GetSecret())
 
-		entries = append(entries, &Entry{
+		entry := &Entry{
 			ID:        xid.New().String(),
 			Issuer:    issuer,
 			Account:   account,
 			Secret:    strings.ToUpper(secret),
-			Algorithm: migrationAlgorithm(otp.GetAlgorithm()),
 			Digits:    migrationDigits(otp.GetDigits()),
-			Period:    30,
+			Period:    totp.DefaultPeriod,
+			Counter:   uint64(max(otp.GetCounter(), 0)),
 			Order:     0,
 			CreatedAt: time.Now(),
-		})
+		}
+
+		// TOTP/HOTP以外の種別は取り込まない
+		otpType, ok := migrationType(otp.GetType())
+		if !ok {
+			warnings = append(warnings, ImportWarning{Name: entry.DisplayName(), Skipped: true, Err: ErrUnsupportedOTPType})
+			continue
+		}
+		entry.Type = otpType
+
+		// 対応していないアルゴリズムは誤ったコードを生成するため取り込まない
+		algorithm, err := migrationAlgorithm(otp.GetAlgorithm())
+		if algorithm == "" {
+			warnings = append(warnings, ImportWarning{Name: entry.DisplayName(), Skipped: true, Err: err})
+			continue
+		}
+		entry.Algorithm = algorithm
+
+		// 無効なパラメータのエントリは取り込まない
+		if err := entry.Validate(); err != nil {
+			warnings = append(warnings, ImportWarning{Name: entry.DisplayName(), Skipped: true, Err: err})
+			continue
+		}
+
+		// アルゴリズムを推定した場合は取り込んだ上で警告する
+		if err != nil {
+			warnings = append(warnings, ImportWarning{Name: entry.DisplayName(), Err: err})
+		}
+		entries = append(entries, entry)
 	}
 
 	if len(entries) == 0 {
-		return nil, ErrNoTOTPEntries
+		return nil, warnings, ErrNoTOTPEntries
+	}
+
+	return entries, warnings, nil
+}
+
+// ExportOTPAuthMigrationURIs は複数のEntryをbatchSize件ずつのotpauth-migration:// URIに変換し、
+// URIと項目ごとの警告（migrationデータで表せないため含めなかった項目）を返す
+// エクスポートできるエントリがない場合はErrNoMigrationEntriesとともに警告を返す
+func ExportOTPAuthMigrationURIs(entries []*Entry, batchSize int) ([]string, []ImportWarning, error) {
+	var exportable []*Entry
+	var warnings []ImportWarning
+	for _, e := range entries {
+		if err := checkMigrationParams(e); err != nil {
+			warnings = append(warnings, ImportWarning{Name: e.DisplayName(), Skipped: true, Err: err})
+			continue
+		}
+		exportable = append(exportable, e)
+	}
+	if len(exportable) == 0 {
+		return nil, warnings, ErrNoMigrationEntries
 	}
 
-	return entries, nil
+	var uris []string
+	for batch := range slices.Chunk(exportable, max(batchSize, 1)) {
+		uri, err := BuildOTPAuthMigrationURI(batch)
+		if err != nil {
+			return nil, warnings, err
+		}
+		uris = append(uris, uri)
+	}
+	return uris, warnings, nil
+}
+
+// BuildOTPAuthMigrationURI は複数のEntryからotpauth-migration:// URIを生成する
+// migrationデータで表せないパラメータのエントリを含む場合はErrUnsupportedMigrationParamsを返す
+func BuildOTPAuthMigrationURI(entries []*Entry) (string, error) {
+	payload := &migration.MigrationPayload{}
+	for _, e := range entries {
+		if err := checkMigrationParams(e); err != nil {
+			return "", fmt.Errorf("%s: %w", e.DisplayName(), err)
+		}
+		normalized, err := ParseSecret(e.Secret)
+		if err != nil {
+			return "", err
+		}
+		secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+		if err != nil {
+			return "", ErrInvalidSecret
+		}
+
+		name := e.Account
+		if e.Issuer != "" {
+			name = e.Issuer + ":" + e.Account
+		}
+
+		otpType := migration.MigrationPayload_TOTP
+		var counter int64
+		if e.IsHOTP() {
+			otpType = migration.MigrationPayload_HOTP
+			counter = int64(e.Counter)
+		}
+
+		payload.OtpParameters = append(payload.OtpParameters, &migration.MigrationPayload_OtpParameters{
+			Secret:    secret,
+			Name:      name,
+			Issuer:    e.Issuer,
+			Algorithm: toMigrationAlgorithm(e.Algorithm),
+			Digits:    toMigrationDigits(e.Digits),
+			Type:      otpType,
+			Counter:   counter,
+		})
+	}
+
+	data, err := proto.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("data", base64.StdEncoding.EncodeToString(data))
+	return "otpauth-migration://offline?" + params.Encode(), nil
+}
+
+// checkMigrationParams はエントリのパラメータをmigrationデータで表せるかどうかを検証する
+// migrationデータは桁数6・8、更新間隔30秒、開始時刻0、10進数のコードのみを表せる
+func checkMigrationParams(e *Entry) error {
+	if e.Digits != 6 && e.Digits != 8 {
+		return fmt.Errorf("%w: %d digits", ErrUnsupportedMigrationParams, e.Digits)
+	}
+	if e.Encoder != "" && e.Encoder != totp.EncoderDecimal {
+		return fmt.Errorf("%w: %s encoder", ErrUnsupportedMigrationParams, e.Encoder)
+	}
+	if e.IsHOTP() {
+		if e.Counter > math.MaxInt64 {
+			return fmt.Errorf("%w: counter %d", ErrUnsupportedMigrationParams, e.Counter)
+		}
+		return nil
+	}
+	if e.Period != totp.DefaultPeriod {
+		return fmt.Errorf("%w: period %d", ErrUnsupportedMigrationParams, e.Period)
+	}
+	if e.T0 != 0 {
+		return fmt.Errorf("%w: t0 %d", ErrUnsupportedMigrationParams, e.T0)
+	}
+	return nil
 }
 
 // parseMigrationName はmigrationのname/issuerフィールドからissuerとaccountを抽出する
@@ -90,15 +250,35 @@ func parseMigrationName(name, issuer string) (string, string) {
 	return issuer, name
 }
 
-// migrationAlgorithm はProtobufのAlgorithmをEntry用の文字列に変換する
-func migrationAlgorithm(algo migration.MigrationPayload_Algorithm) string {
+// migrationType はProtobufのOtpTypeをEntry用の種別に変換する
+func migrationType(otpType migration.MigrationPayload_OtpType) (string, bool) {
+	switch otpType {
+	case migration.MigrationPayload_TOTP:
+		return TypeTOTP, true
+	case migration.MigrationPayload_HOTP:
+		return TypeHOTP, true
+	default:
+		return "", false
+	}
+}
+
+// migrationAlgorithm はProtobufのAlgorithmをEntry用のAlgorithmに変換する
+// 未指定の場合はSHA1とみなしてErrUnspecifiedAlgorithmを返し、
+// 未知の値の場合は空のAlgorithmとtotp.ErrUnsupportedAlgorithmを返す
+func migrationAlgorithm(algo migration.MigrationPayload_Algorithm) (totp.Algorithm, error) {
 	switch algo {
+	case migration.MigrationPayload_SHA1:
+		return totp.AlgorithmSHA1, nil
 	case migration.MigrationPayload_SHA256:
-		return "SHA256"
+		return totp.AlgorithmSHA256, nil
 	case migration.MigrationPayload_SHA512:
-		return "SHA512"
+		return totp.AlgorithmSHA512, nil
+	case migration.MigrationPayload_MD5:
+		return totp.AlgorithmMD5, nil
+	case migration.MigrationPayload_ALGORITHM_UNSPECIFIED:
+		return totp.AlgorithmSHA1, ErrUnspecifiedAlgorithm
 	default:
-		return "SHA1"
+		return "", fmt.Errorf("%w: %d", totp.ErrUnsupportedAlgorithm, algo)
 	}
 }
 
@@ -111,3 +291,25 @@ func migrationDigits(digits migration.MigrationPayload_DigitCount) int {
 		return 6
 	}
 }
+
+// toMigrationAlgorithm はEntryのAlgorithmをProtobufのAlgorithmに変換する
+func toMigrationAlgorithm(algo totp.Algorithm) migration.MigrationPayload_Algorithm {
+	switch algo {
+	case totp.AlgorithmSHA256:
+		return migration.MigrationPayload_SHA256
+	case totp.AlgorithmSHA512:
+		return migration.MigrationPayload_SHA512
+	case totp.AlgorithmMD5:
+		return migration.MigrationPayload_MD5
+	default:
+		return migration.MigrationPayload_SHA1
+	}
+}
+
+// toMigrationDigits はEntryの桁数をProtobufのDigitCountに変換する
+func toMigrationDigits(digits int) migration.MigrationPayload_DigitCount {
+	if digits == 8 {
+		return migration.MigrationPayload_EIGHT
+	}
+	return migration.MigrationPayload_SIX
+}
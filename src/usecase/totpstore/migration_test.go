@@ -3,8 +3,10 @@ package totpstore
 import (
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/nktmys/winticator/src/usecase/totpstore/migration"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,7 +43,7 @@ func TestParseOTPAuthMigrationURI_SingleEntry(t *testing.T) {
 	assert.Equal(t, "Google", entry.Issuer)
 	assert.Equal(t, "user@gmail.com", entry.Account)
 	assert.NotEmpty(t, entry.Secret)
-	assert.Equal(t, "SHA1", entry.Algorithm)
+	assert.Equal(t, totp.AlgorithmSHA1, entry.Algorithm)
 	assert.Equal(t, 6, entry.Digits)
 	assert.Equal(t, 30, entry.Period)
 	assert.NotEmpty(t, entry.ID)
@@ -73,25 +75,26 @@ func TestParseOTPAuthMigrationURI_MultipleEntries(t *testing.T) {
 
 	assert.Equal(t, "Google", entries[0].Issuer)
 	assert.Equal(t, "user1@gmail.com", entries[0].Account)
-	assert.Equal(t, "SHA1", entries[0].Algorithm)
+	assert.Equal(t, totp.AlgorithmSHA1, entries[0].Algorithm)
 	assert.Equal(t, 6, entries[0].Digits)
 
 	assert.Equal(t, "GitHub", entries[1].Issuer)
 	assert.Equal(t, "myaccount", entries[1].Account)
-	assert.Equal(t, "SHA256", entries[1].Algorithm)
+	assert.Equal(t, totp.AlgorithmSHA256, entries[1].Algorithm)
 	assert.Equal(t, 8, entries[1].Digits)
 
 	// 各エントリのIDが一意であること
 	assert.NotEqual(t, entries[0].ID, entries[1].ID)
 }
 
-func TestParseOTPAuthMigrationURI_SkipsHOTP(t *testing.T) {
+func TestParseOTPAuthMigrationURI_IncludesHOTP(t *testing.T) {
 	uri := buildMigrationURI([]*migration.MigrationPayload_OtpParameters{
 		{
-			Secret: []byte("hotpsecrethotpsecret"),
-			Name:   "HOTP:counter",
-			Issuer: "HOTPService",
-			Type:   migration.MigrationPayload_HOTP,
+			Secret:  []byte("hotpsecrethotpsecret"),
+			Name:    "HOTP:counter",
+			Issuer:  "HOTPService",
+			Type:    migration.MigrationPayload_HOTP,
+			Counter: 12,
 		},
 		{
 			Secret: []byte("totpsecrettotpsecret"),
@@ -103,10 +106,16 @@ func TestParseOTPAuthMigrationURI_SkipsHOTP(t *testing.T) {
 
 	entries, err := ParseOTPAuthMigrationURI(uri)
 	require.NoError(t, err)
-	require.Len(t, entries, 1)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "HOTPService", entries[0].Issuer)
+	assert.Equal(t, "counter", entries[0].Account)
+	assert.Equal(t, TypeHOTP, entries[0].Type)
+	assert.Equal(t, uint64(12), entries[0].Counter)
 
-	assert.Equal(t, "TOTPService", entries[0].Issuer)
-	assert.Equal(t, "timer", entries[0].Account)
+	assert.Equal(t, "TOTPService", entries[1].Issuer)
+	assert.Equal(t, "timer", entries[1].Account)
+	assert.Equal(t, TypeTOTP, entries[1].Type)
 }
 
 func TestParseOTPAuthMigrationURI_NameWithoutIssuer(t *testing.T) {
@@ -161,7 +170,7 @@ func TestParseOTPAuthMigrationURI_SHA512Algorithm(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, entries, 1)
 
-	assert.Equal(t, "SHA512", entries[0].Algorithm)
+	assert.Equal(t, totp.AlgorithmSHA512, entries[0].Algorithm)
 }
 
 func TestParseOTPAuthMigrationURI_InvalidScheme(t *testing.T) {
@@ -198,8 +207,119 @@ func TestParseOTPAuthMigrationURI_OnlyHOTPEntries(t *testing.T) {
 		},
 	})
 
-	_, err := ParseOTPAuthMigrationURI(uri)
-	assert.ErrorIs(t, err, ErrNoTOTPEntries)
+	entries, err := ParseOTPAuthMigrationURI(uri)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].IsHOTP())
+}
+
+func TestBuildOTPAuthMigrationURI_RoundTrip(t *testing.T) {
+	source := []*Entry{
+		{
+			Issuer:    "Google",
+			Account:   "user@gmail.com",
+			Type:      TypeTOTP,
+			Secret:    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+			Algorithm: totp.AlgorithmSHA256,
+			Digits:    8,
+			Period:    30,
+		},
+		{
+			Issuer:    "VPN",
+			Account:   "token",
+			Type:      TypeHOTP,
+			Secret:    "JBSWY3DPEHPK3PXP",
+			Algorithm: "SHA1",
+			Digits:    6,
+			Period:    30,
+			Counter:   99,
+		},
+	}
+
+	uri, err := BuildOTPAuthMigrationURI(source)
+	require.NoError(t, err)
+
+	entries, err := ParseOTPAuthMigrationURI(uri)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	for i, want := range source {
+		assert.Equal(t, want.Issuer, entries[i].Issuer)
+		assert.Equal(t, want.Account, entries[i].Account)
+		assert.Equal(t, want.Type, entries[i].Type)
+		assert.Equal(t, want.Secret, entries[i].Secret)
+		assert.Equal(t, want.Algorithm, entries[i].Algorithm)
+		assert.Equal(t, want.Digits, entries[i].Digits)
+		assert.Equal(t, want.Counter, entries[i].Counter)
+	}
+}
+
+func TestBuildOTPAuthMigrationURI_InvalidSecret(t *testing.T) {
+	_, err := BuildOTPAuthMigrationURI([]*Entry{NewEntry("Test", "user", "invalid!@#$")})
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestBuildOTPAuthMigrationURI_UnsupportedParams(t *testing.T) {
+	// migrationデータで表せないパラメータは黙って変更せずにエラーとする
+	tests := []struct {
+		name   string
+		modify func(e *Entry)
+	}{
+		{name: "period", modify: func(e *Entry) { e.Period = 60 }},
+		{name: "t0", modify: func(e *Entry) { e.T0 = 100 }},
+		{name: "digits", modify: func(e *Entry) { e.Digits = 7 }},
+		{name: "encoder", modify: func(e *Entry) { e.Encoder = totp.EncoderSteam; e.Digits = 5 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := NewEntry("Test", "user", "JBSWY3DPEHPK3PXP")
+			tt.modify(entry)
+			_, err := BuildOTPAuthMigrationURI([]*Entry{entry})
+			assert.ErrorIs(t, err, ErrUnsupportedMigrationParams)
+		})
+	}
+
+	// HOTPは更新間隔を使用しないため検証しない
+	hotp := NewEntry("VPN", "token", "JBSWY3DPEHPK3PXP")
+	hotp.Type = TypeHOTP
+	hotp.Period = 60
+	_, err := BuildOTPAuthMigrationURI([]*Entry{hotp})
+	assert.NoError(t, err)
+}
+
+func TestExportOTPAuthMigrationURIs(t *testing.T) {
+	var entries []*Entry
+	for i := range 5 {
+		entries = append(entries, NewEntry(fmt.Sprintf("Service%d", i), "user", "JBSWY3DPEHPK3PXP"))
+	}
+	steam := NewEntry("Steam", "user", "JBSWY3DPEHPK3PXP")
+	steam.Encoder = totp.EncoderSteam
+	steam.Digits = 5
+	entries = append(entries, steam)
+
+	// batchSize件ずつのURIに分け、表せないエントリは警告として除外する
+	uris, warnings, err := ExportOTPAuthMigrationURIs(entries, 2)
+	require.NoError(t, err)
+	require.Len(t, uris, 3)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "Steam: user", warnings[0].Name)
+	assert.True(t, warnings[0].Skipped)
+	assert.ErrorIs(t, warnings[0], ErrUnsupportedMigrationParams)
+
+	var imported []*Entry
+	for _, uri := range uris {
+		parsed, err := ParseOTPAuthMigrationURI(uri)
+		require.NoError(t, err)
+		imported = append(imported, parsed...)
+	}
+	require.Len(t, imported, 5)
+	assert.Equal(t, "Service4", imported[4].Issuer)
+
+	// エクスポートできるエントリがない場合
+	_, warnings, err = ExportOTPAuthMigrationURIs([]*Entry{steam}, 2)
+	assert.ErrorIs(t, err, ErrNoMigrationEntries)
+	assert.Len(t, warnings, 1)
 }
 
 func TestParseOTPAuthMigrationURI_EmptyPayload(t *testing.T) {
@@ -216,3 +336,111 @@ func TestParseOTPAuthMigrationURI_EmptyPayload(t *testing.T) {
 	_, err := ParseOTPAuthMigrationURI(uri)
 	assert.ErrorIs(t, err, ErrNoTOTPEntries)
 }
+
+func TestParseOTPAuthMigrationURI_MD5Algorithm(t *testing.T) {
+	uri := buildMigrationURI([]*migration.MigrationPayload_OtpParameters{
+		{
+			Secret:    []byte("12345678901234567890"),
+			Name:      "Test:user",
+			Algorithm: migration.MigrationPayload_MD5,
+			Type:      migration.MigrationPayload_TOTP,
+		},
+	})
+
+	entries, warnings, err := ParseOTPAuthMigrationURIWithWarnings(uri)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Empty(t, warnings)
+	assert.Equal(t, totp.AlgorithmMD5, entries[0].Algorithm)
+
+	// エクスポートでもMD5が保持されること
+	exported, err := BuildOTPAuthMigrationURI(entries)
+	require.NoError(t, err)
+	reimported, err := ParseOTPAuthMigrationURI(exported)
+	require.NoError(t, err)
+	assert.Equal(t, totp.AlgorithmMD5, reimported[0].Algorithm)
+}
+
+func TestParseOTPAuthMigrationURI_AlgorithmWarnings(t *testing.T) {
+	uri := buildMigrationURI([]*migration.MigrationPayload_OtpParameters{
+		{
+			Secret:    []byte("12345678901234567890"),
+			Name:      "Unspecified:user",
+			Algorithm: migration.MigrationPayload_ALGORITHM_UNSPECIFIED,
+			Type:      migration.MigrationPayload_TOTP,
+		},
+		{
+			Secret:    []byte("12345678901234567890"),
+			Name:      "Unknown:user",
+			Algorithm: migration.MigrationPayload_Algorithm(99),
+			Type:      migration.MigrationPayload_TOTP,
+		},
+		{
+			Secret:    []byte("12345678901234567890"),
+			Name:      "Valid:user",
+			Algorithm: migration.MigrationPayload_SHA256,
+			Type:      migration.MigrationPayload_TOTP,
+		},
+	})
+
+	entries, warnings, err := ParseOTPAuthMigrationURIWithWarnings(uri)
+	require.NoError(t, err)
+
+	// 未指定はSHA1とみなして取り込み、未知のアルゴリズムは取り込まない
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Unspecified", entries[0].Issuer)
+	assert.Equal(t, totp.AlgorithmSHA1, entries[0].Algorithm)
+	assert.Equal(t, "Valid", entries[1].Issuer)
+
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "Unspecified: user", warnings[0].Name)
+	assert.False(t, warnings[0].Skipped)
+	require.ErrorIs(t, warnings[0], ErrUnspecifiedAlgorithm)
+	assert.Equal(t, "Unknown: user", warnings[1].Name)
+	assert.True(t, warnings[1].Skipped)
+	require.ErrorIs(t, warnings[1], totp.ErrUnsupportedAlgorithm)
+}
+
+func TestParseOTPAuthMigrationURI_AllSkipped(t *testing.T) {
+	uri := buildMigrationURI([]*migration.MigrationPayload_OtpParameters{
+		{
+			Secret:    []byte("12345678901234567890"),
+			Name:      "Unknown:user",
+			Algorithm: migration.MigrationPayload_Algorithm(99),
+			Type:      migration.MigrationPayload_TOTP,
+		},
+	})
+
+	// 取り込めるエントリがない場合も警告を返す
+	_, warnings, err := ParseOTPAuthMigrationURIWithWarnings(uri)
+	require.ErrorIs(t, err, ErrNoTOTPEntries)
+	require.Len(t, warnings, 1)
+	assert.True(t, warnings[0].Skipped)
+}
+
+func TestParseOTPAuthMigrationURI_UnsupportedTypeWarnings(t *testing.T) {
+	uri := buildMigrationURI([]*migration.MigrationPayload_OtpParameters{
+		{
+			Secret: []byte("12345678901234567890"),
+			Name:   "Unspecified:user",
+			Type:   migration.MigrationPayload_OTP_TYPE_UNSPECIFIED,
+		},
+		{
+			Secret:    []byte("12345678901234567890"),
+			Name:      "Valid:user",
+			Algorithm: migration.MigrationPayload_SHA1,
+			Type:      migration.MigrationPayload_TOTP,
+		},
+	})
+
+	// TOTP/HOTP以外の種別は取り込まずに警告する
+	entries, warnings, err := ParseOTPAuthMigrationURIWithWarnings(uri)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Valid", entries[0].Issuer)
+
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "Unspecified: user", warnings[0].Name)
+	assert.True(t, warnings[0].Skipped)
+	require.ErrorIs(t, warnings[0], ErrUnsupportedOTPType)
+}
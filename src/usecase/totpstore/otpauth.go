@@ -0,0 +1,251 @@
+package totpstore
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nktmys/winticator/src/pkg/totp"
+)
+
+// knownURIParams はEntryのフィールドとして解釈するotpauth URIのクエリパラメータ
+var knownURIParams = []string{
+	"secret",
+	"issuer",
+	"algorithm",
+	"digits",
+	"period",
+	"counter",
+	"encoder",
+	"t0",
+	"tags",
+}
+
+// URIParam はotpauth URIのクエリパラメータ（キーと値はデコード済み）
+// Rawは元のURIでのエンコードされた"key=value"の文字列で、URIを構築する際にそのまま出力する
+// Entryのフィールドとして解釈したパラメータやアイコンとして取り込んだパラメータは、値をフィールドに保持してValueを持たない
+type URIParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	Raw   string `json:"raw,omitempty"`
+	Icon  bool   `json:"icon,omitempty"` // アイコンとして取り込んだimageパラメータ
+}
+
+// parseLabel はotpauth URIのエスケープされたパスからissuerとaccountを抽出する
+// 形式: /ISSUER:ACCOUNT または /ACCOUNT（区切りの":"は"%3A"でもよく、前後の空白は無視する）
+// issuer自体に"%3A"を含む場合に備え、エスケープされていない":"を優先して区切りとする
+func parseLabel(escapedPath string) (string, string, error) {
+	label := strings.TrimPrefix(escapedPath, "/")
+
+	sep, sepLen := strings.Index(label, ":"), 1
+	if sep < 0 {
+		sep, sepLen = strings.Index(strings.ToUpper(label), "%3A"), 3
+	}
+
+	rawIssuer, rawAccount := "", label
+	if sep >= 0 {
+		rawIssuer, rawAccount = label[:sep], label[sep+sepLen:]
+	}
+
+	issuer, err := url.PathUnescape(rawIssuer)
+	if err != nil {
+		return "", "", ErrInvalidURIEncoding
+	}
+	account, err := url.PathUnescape(rawAccount)
+	if err != nil {
+		return "", "", ErrInvalidURIEncoding
+	}
+	return strings.TrimSpace(issuer), strings.TrimSpace(account), nil
+}
+
+// parseQueryParams はクエリ文字列を出現順のパラメータ列にデコードする
+// url.ParseQueryと異なり順序を保持し、不正なエスケープはエラーとする
+func parseQueryParams(rawQuery string) ([]URIParam, error) {
+	var params []URIParam
+	for pair := range strings.SplitSeq(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			return nil, ErrInvalidURIEncoding
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			return nil, ErrInvalidURIEncoding
+		}
+		params = append(params, URIParam{Key: key, Value: value, Raw: pair})
+	}
+	return params, nil
+}
+
+// queryValues はパラメータ列をurl.Valuesに変換する（同じキーは出現順に保持される）
+func queryValues(params []URIParam) url.Values {
+	values := url.Values{}
+	for _, p := range params {
+		values.Add(p.Key, p.Value)
+	}
+	return values
+}
+
+// compactURIParams は元のURIのパラメータ列から、Entryのフィールドと二重に保存する値を取り除く
+// 既知のパラメータは値をフィールドに保持し、シークレットとアイコンはフィールドから同じ文字列を出力できる場合はRawも保持しない
+func (e *Entry) compactURIParams() {
+	for i, p := range e.URIParams {
+		switch {
+		case p.Icon:
+			e.URIParams[i].Value = ""
+			if p.Raw == encodeQueryParam(p.Key, e.iconDataURI()) {
+				e.URIParams[i].Raw = ""
+			}
+		case slices.Contains(knownURIParams, p.Key):
+			e.URIParams[i].Value = ""
+			if p.Key == "secret" && p.Raw == encodeQueryParam(p.Key, e.Secret) {
+				e.URIParams[i].Raw = ""
+			}
+		}
+	}
+}
+
+// uriLabel はotpauth URIのエスケープされたラベルと、ラベルが表すissuerを返す
+// 元のURIのラベルが現在のissuerとaccountを表す場合はそのまま返す
+func (e *Entry) uriLabel() (string, string) {
+	if e.URILabel != "" {
+		issuer, account, err := parseLabel(e.URILabel)
+		if err == nil && account == e.Account && (issuer == e.Issuer || e.uriParamUnchanged("issuer")) {
+			return e.URILabel, issuer
+		}
+	}
+	if e.Issuer != "" {
+		return escapeLabelPart(e.Issuer) + ":" + escapeLabelPart(e.Account), ""
+	}
+	return escapeLabelPart(e.Account), ""
+}
+
+// uriQuery はotpauth URIのクエリ文字列を構築する
+// 元のURIのパラメータは出現順に、値が変わっていなければ元の文字列のまま出力し、変わった場合は現在の値を出力する（既定値に変わった場合は省略する）
+// 元のURIになかった既知のパラメータは、既定値でない場合のみ末尾に付加する
+// 元のURIのラベルがissuerを表す場合は、元のURIになかったissuerパラメータを付加しない
+func (e *Entry) uriQuery(labelIssuer string) string {
+	var pairs []string
+	written := make(map[string]bool)
+	for _, p := range e.URIParams {
+		switch {
+		case p.Icon:
+			// アイコンを変更した場合はパラメータが削除される（QRコードに収まらないため新しいアイコンは含めない）
+			if e.HasIcon() {
+				pairs = append(pairs, cmp.Or(p.Raw, encodeQueryParam(p.Key, e.iconDataURI())))
+			}
+		case slices.Contains(knownURIParams, p.Key):
+			if p.Raw != "" && e.uriParamMatches(p.Key, rawParamValue(p.Raw)) {
+				pairs = append(pairs, p.Raw)
+			} else if value, ok, omittable := e.uriParamValue(p.Key); ok && !omittable && !written[p.Key] {
+				pairs = append(pairs, encodeQueryParam(p.Key, value))
+			} else {
+				continue
+			}
+			written[p.Key] = true
+		default:
+			pairs = append(pairs, cmp.Or(p.Raw, encodeQueryParam(p.Key, p.Value)))
+		}
+	}
+
+	written["issuer"] = written["issuer"] || (labelIssuer != "" && labelIssuer == e.Issuer)
+	for _, key := range knownURIParams {
+		if value, ok, omittable := e.uriParamValue(key); ok && !omittable && !written[key] {
+			pairs = append(pairs, encodeQueryParam(key, value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriParamValue はEntryのフィールドとして解釈するパラメータの現在の値を返す
+// 出力できない場合はokをfalseとし、既定値のため省略できる場合はomittableをtrueとする
+// エンコーダーは他のアプリでも同じコードになるよう省略しない
+func (e *Entry) uriParamValue(key string) (value string, ok bool, omittable bool) {
+	switch key {
+	case "secret":
+		return e.Secret, true, false
+	case "issuer":
+		return e.Issuer, e.Issuer != "", false
+	case "algorithm":
+		return string(e.Algorithm), true, e.Algorithm == totp.AlgorithmSHA1
+	case "digits":
+		return strconv.Itoa(e.Digits), true, e.Digits == totp.DefaultDigits
+	case "encoder":
+		return e.Encoder, e.Encoder != "", false
+	case "tags":
+		return FormatTags(e.Tags), len(e.Tags) > 0, false
+	case "counter":
+		return strconv.FormatUint(e.Counter, 10), e.IsHOTP(), false
+	case "period":
+		return strconv.Itoa(e.Period), !e.IsHOTP(), e.Period == totp.DefaultPeriod
+	case "t0":
+		return strconv.FormatInt(e.T0, 10), !e.IsHOTP(), e.T0 == 0
+	}
+	return "", false, false
+}
+
+// uriParamMatches は元のURIでのパラメータの値がEntryのフィールドの現在の値と同じ意味かどうかを返す
+func (e *Entry) uriParamMatches(key, value string) bool {
+	switch key {
+	case "secret":
+		return strings.ToUpper(value) == e.Secret
+	case "issuer":
+		return value == e.Issuer
+	case "algorithm":
+		algorithm, err := totp.ParseAlgorithm(cmp.Or(value, string(totp.AlgorithmSHA1)))
+		return err == nil && algorithm == e.Algorithm
+	case "digits":
+		digits, err := strconv.Atoi(value)
+		return err == nil && digits == e.Digits
+	case "encoder":
+		return detectEncoder(value, e.Issuer) == e.Encoder
+	case "tags":
+		return slices.Equal(ParseTags(value), e.Tags)
+	case "counter":
+		counter, err := strconv.ParseUint(value, 10, 64)
+		return e.IsHOTP() && err == nil && counter == e.Counter
+	case "period":
+		period, err := strconv.Atoi(value)
+		return !e.IsHOTP() && err == nil && period == e.Period
+	case "t0":
+		t0, err := strconv.ParseInt(value, 10, 64)
+		return !e.IsHOTP() && err == nil && t0 == e.T0
+	}
+	return false
+}
+
+// uriParamUnchanged は元のURIのパラメータが現在もEntryのフィールドと同じ値かどうかを返す
+func (e *Entry) uriParamUnchanged(key string) bool {
+	for _, p := range e.URIParams {
+		if p.Key == key && p.Raw != "" {
+			return e.uriParamMatches(key, rawParamValue(p.Raw))
+		}
+	}
+	return false
+}
+
+// rawParamValue はエンコードされた"key=value"の文字列から値をデコードする
+func rawParamValue(raw string) string {
+	_, rawValue, _ := strings.Cut(raw, "=")
+	value, err := url.QueryUnescape(rawValue)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// escapeLabelPart はラベルのissuerまたはaccountをエスケープする
+// 区切り文字と区別するため":"もエスケープする
+func escapeLabelPart(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), ":", "%3A")
+}
+
+// encodeQueryParam はクエリパラメータを"key=value"の文字列にエンコードする
+func encodeQueryParam(key, value string) string {
+	return url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
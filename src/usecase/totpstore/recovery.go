@@ -0,0 +1,49 @@
+package totpstore
+
+// LoadFailure は保存データを読み込めなかった状態（復旧モード）を表す
+type LoadFailure struct {
+	Err            error  // 読み込めなかった原因
+	QuarantinePath string // 隔離した保存データのパス（隔離できなかった場合は空）
+}
+
+// Quarantiner は読み込めなかった保存データを別の場所に隔離できるBackend
+type Quarantiner interface {
+	// Quarantine は保存データを隔離し、隔離先のパスを返す
+	Quarantine(data []byte) (string, error)
+}
+
+// LoadFailure は保存データを読み込めなかった場合にその状態を返す（復旧モードでない場合はnil）
+func (s *Store) LoadFailure() *LoadFailure {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.failure == nil {
+		return nil
+	}
+	failure := *s.failure
+	return &failure
+}
+
+// AbandonRecovery は読み込めなかった保存データの復旧をあきらめ、保存できるようにする
+// 隔離できなかった保存データは次回の保存で上書きされる
+func (s *Store) AbandonRecovery() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failure = nil
+}
+
+// enterRecovery は保存データを読み込めなかった場合に空のストアとして復旧モードにする
+// 保存先が対応している場合は読み込めなかった保存データを隔離する（ロックは呼び出し側で取得する）
+func (s *Store) enterRecovery(data []byte, err error) {
+	s.entries = make([]*Entry, 0)
+	s.trash = nil
+	s.loaded = true
+	s.failure = &LoadFailure{Err: err}
+
+	if quarantiner, ok := s.backend.(Quarantiner); ok && len(data) > 0 {
+		if path, err := quarantiner.Quarantine(data); err == nil {
+			s.failure.QuarantinePath = path
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package totpstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/nktmys/winticator/src/usecase/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encryptVaultWithKey はテスト用に指定した鍵で暗号化した保存データを作成する
+func encryptVaultWithKey(t *testing.T, key []byte, entries ...*Entry) []byte {
+	t.Helper()
+	data, err := EncodeVault(entries)
+	require.NoError(t, err)
+	encrypted, err := crypto.Encrypt(string(key), data)
+	require.NoError(t, err)
+	return encrypted
+}
+
+func TestStore_Load_QuarantinesUnreadableVault(t *testing.T) {
+	machinekey.ResetCache()
+
+	// 別のマシンの鍵で暗号化された保存データ
+	unreadable := encryptVaultWithKey(t, []byte("previous-machine-key"), NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP"))
+
+	backend := NewFileBackend(filepath.Join(t.TempDir(), VaultFileName))
+	require.NoError(t, backend.Save(unreadable))
+
+	store := NewWithBackend(backend)
+	err := store.Load()
+	require.ErrorIs(t, err, ErrVaultUnreadable)
+	assert.Equal(t, 0, store.Count())
+
+	// 読み込めなかった保存データは隔離される
+	failure := store.LoadFailure()
+	require.NotNil(t, failure)
+	require.NotEmpty(t, failure.QuarantinePath)
+	quarantined, err := os.ReadFile(failure.QuarantinePath)
+	require.NoError(t, err)
+	assert.Equal(t, unreadable, quarantined)
+
+	// 再度読み込んでも同じ内容を重複して隔離しない
+	require.ErrorIs(t, NewWithBackend(backend).Load(), ErrVaultUnreadable)
+	dirEntries, err := os.ReadDir(filepath.Dir(failure.QuarantinePath))
+	require.NoError(t, err)
+	assert.Len(t, dirEntries, 1)
+
+	// 隔離済みのため、復旧モード中も保存できる
+	require.NoError(t, store.Add(NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ")))
+	require.NoError(t, store.Save())
+	quarantined, err = os.ReadFile(failure.QuarantinePath)
+	require.NoError(t, err)
+	assert.Equal(t, unreadable, quarantined)
+}
+
+func TestStore_Load_LocksUnquarantinedVault(t *testing.T) {
+	machinekey.ResetCache()
+
+	backend := NewMemoryBackend()
+	require.NoError(t, backend.Save([]byte("corrupted vault data")))
+
+	store := NewWithBackend(backend)
+	require.ErrorIs(t, store.Load(), ErrVaultUnreadable)
+
+	failure := store.LoadFailure()
+	require.NotNil(t, failure)
+	assert.Empty(t, failure.QuarantinePath)
+
+	// 隔離できない場合は読み込めなかった保存データを上書きしない
+	require.NoError(t, store.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")))
+	require.ErrorIs(t, store.Save(), ErrVaultLocked)
+	data, err := backend.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("corrupted vault data"), data)
+
+	// 復旧をあきらめた場合は保存できる
+	store.AbandonRecovery()
+	assert.Nil(t, store.LoadFailure())
+	require.NoError(t, store.Save())
+}
+
+func TestTx_AbandonRecovery(t *testing.T) {
+	machinekey.ResetCache()
+
+	backend := NewMemoryBackend()
+	require.NoError(t, backend.Save([]byte("corrupted vault data")))
+	store := NewWithBackend(backend)
+	require.ErrorIs(t, store.Load(), ErrVaultUnreadable)
+
+	// 変更を取り消した場合は復旧モードのまま保存データを上書きしない
+	err := store.Batch(func(tx *Tx) error {
+		tx.AbandonRecovery()
+		tx.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP"))
+		return ErrEntryNotFound
+	})
+	require.ErrorIs(t, err, ErrEntryNotFound)
+	assert.NotNil(t, store.LoadFailure())
+	assert.Equal(t, 0, store.Count())
+	require.ErrorIs(t, store.Save(), ErrVaultLocked)
+
+	// 変更を保存できた場合のみ復旧モードを解除する
+	require.NoError(t, store.Batch(func(tx *Tx) error {
+		tx.AbandonRecovery()
+		tx.Add(NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP"))
+		return nil
+	}))
+	assert.Nil(t, store.LoadFailure())
+
+	loaded := NewWithBackend(backend)
+	require.NoError(t, loaded.Load())
+	assert.Equal(t, 1, loaded.Count())
+}
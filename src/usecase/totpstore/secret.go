@@ -0,0 +1,31 @@
+package totpstore
+
+import (
+	"strings"
+)
+
+// NormalizeSecret はシークレットを正規化する（大文字化し、空白・ハイフン・パディングを除く）
+func NormalizeSecret(secret string) string {
+	secret = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', '-':
+			return -1
+		}
+		return r
+	}, secret)
+	return strings.TrimRight(strings.ToUpper(secret), "=")
+}
+
+// ParseSecret は手入力や貼り付けたシークレットを正規化し、Base32として有効かどうかを検証する
+// 空白・ハイフン・小文字・パディングの有無を許容し、正規化したシークレットを返す
+func ParseSecret(secret string) (string, error) {
+	normalized := NormalizeSecret(secret)
+	if normalized == "" {
+		return "", ErrInvalidSecret
+	}
+	// コード生成と同じ方法でデコードできることを確認する
+	if err := NewEntry("", "", normalized).Validate(); err != nil {
+		return "", ErrInvalidSecret
+	}
+	return normalized, nil
+}
@@ -0,0 +1,42 @@
+package totpstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"normalized", "JBSWY3DPEHPK3PXP", "JBSWY3DPEHPK3PXP", true},
+		{"spaces and lowercase", "jbsw y3dp ehpk 3pxp", "JBSWY3DPEHPK3PXP", true},
+		{"hyphens", "JBSW-Y3DP-EHPK-3PXP", "JBSWY3DPEHPK3PXP", true},
+		{"missing padding", "JBSWY3DPEE", "JBSWY3DPEE", true},
+		{"with padding", "JBSWY3DPEE======", "JBSWY3DPEE", true},
+		{"empty", "  ", "", false},
+		{"invalid character", "JBSWY3DP1", "", false},
+		{"invalid length", "JBSWY3", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSecret(tt.input)
+			if !tt.wantOK {
+				assert.ErrorIs(t, err, ErrInvalidSecret)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// 正規化したシークレットでコードを生成できること
+			entry := NewEntry("Test", "user", got)
+			assert.NoError(t, entry.Validate())
+		})
+	}
+}
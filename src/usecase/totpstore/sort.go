@@ -0,0 +1,65 @@
+package totpstore
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortMode はエントリリストの並び順
+type SortMode string
+
+// サポートする並び順
+const (
+	SortManual   SortMode = "manual"    // 手動で並べ替えた順（Order順）
+	SortIssuer   SortMode = "issuer"    // サービス名のアルファベット順
+	SortMostUsed SortMode = "most_used" // 使用回数の多い順
+	SortRecent   SortMode = "recent"    // 最近使用した順
+)
+
+// SortModes はサポートする並び順の一覧（選択肢の表示順）
+var SortModes = []SortMode{SortManual, SortIssuer, SortMostUsed, SortRecent}
+
+// ParseSortMode は文字列をSortModeに変換する（不明な値の場合は手動の並び順）
+func ParseSortMode(s string) SortMode {
+	mode := SortMode(s)
+	for _, m := range SortModes {
+		if m == mode {
+			return m
+		}
+	}
+	return SortManual
+}
+
+// SortEntries はエントリを指定した並び順でソートする
+// どの並び順でもピン留めしたエントリを先頭に並べ、同順位の場合はOrder順とする
+func SortEntries(entries []*Entry, mode SortMode) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		switch mode {
+		case SortIssuer:
+			if c := compareFold(a.Issuer, b.Issuer); c != 0 {
+				return c < 0
+			}
+			if c := compareFold(a.Account, b.Account); c != 0 {
+				return c < 0
+			}
+		case SortMostUsed:
+			if a.UseCount != b.UseCount {
+				return a.UseCount > b.UseCount
+			}
+		case SortRecent:
+			if !a.LastUsedAt.Equal(b.LastUsedAt) {
+				return a.LastUsedAt.After(b.LastUsedAt)
+			}
+		}
+		return a.Order < b.Order
+	})
+}
+
+// compareFold は大文字・小文字を区別せずに文字列を比較する
+func compareFold(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
@@ -0,0 +1,52 @@
+package totpstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSortMode(t *testing.T) {
+	assert.Equal(t, SortManual, ParseSortMode(""))
+	assert.Equal(t, SortManual, ParseSortMode("unknown"))
+	assert.Equal(t, SortIssuer, ParseSortMode("issuer"))
+	assert.Equal(t, SortMostUsed, ParseSortMode("most_used"))
+	assert.Equal(t, SortRecent, ParseSortMode("recent"))
+}
+
+func TestSortEntries(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newEntries := func() []*Entry {
+		return []*Entry{
+			{ID: "a", Issuer: "github", Order: 0, UseCount: 3, LastUsedAt: base.Add(time.Hour)},
+			{ID: "b", Issuer: "Amazon", Order: 1, UseCount: 10},
+			{ID: "c", Issuer: "Google", Account: "work", Order: 2, UseCount: 3, LastUsedAt: base.Add(2 * time.Hour)},
+			{ID: "d", Issuer: "Google", Account: "home", Order: 3, Pinned: true},
+			{ID: "e", Issuer: "zoom", Order: 4, UseCount: 1, LastUsedAt: base},
+		}
+	}
+
+	tests := []struct {
+		mode SortMode
+		want []string
+	}{
+		{SortManual, []string{"d", "a", "b", "c", "e"}},
+		{SortIssuer, []string{"d", "b", "a", "c", "e"}},
+		{SortMostUsed, []string{"d", "b", "a", "c", "e"}},
+		{SortRecent, []string{"d", "c", "a", "e", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			entries := newEntries()
+			SortEntries(entries, tt.mode)
+
+			var ids []string
+			for _, e := range entries {
+				ids = append(ids, e.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
@@ -1,72 +1,235 @@
 package totpstore
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"slices"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/nktmys/winticator/src/usecase/crypto"
 	"github.com/nktmys/winticator/src/usecase/preferences"
 )
 
+// usageFlushDelay は使用履歴を記録してから保存するまでの時間
+// コピーのたびに保存データを書き換えないよう、この間の記録をまとめて保存する
+const usageFlushDelay = 30 * time.Second
+
 // Store はTOTPエントリの保存・読み込みを管理する
 type Store struct {
-	prefs   *preferences.Manager
+	backend Backend
+	legacy  Backend // 読み込み時に保存データを移行する以前の保存先
+	clock   totp.Clock
 	entries []*Entry
+	trash   []*Entry // ゴミ箱のエントリ
 	mu      sync.RWMutex
 	loaded  bool
+
+	failure *LoadFailure // 保存データを読み込めなかった場合の状態（復旧モード）
+
+	listeners listeners // 変更イベントを受け取るListener
+	pending   []Event   // ロック解放後に通知する変更イベント
+
+	usageDirty atomic.Bool // 保存していない使用履歴がある（保存に成功するとクリアする）
+	usageTimer *time.Timer // 使用履歴を遅れて保存するタイマー
 }
 
-// New は新しいStoreインスタンスを作成する
+// New はFyneのPreferencesに保存する新しいStoreインスタンスを作成する
 func New(prefs *preferences.Manager) *Store {
+	return NewWithBackend(NewPreferencesBackend(prefs))
+}
+
+// NewWithBackend は指定したBackendに保存する新しいStoreインスタンスを作成する
+func NewWithBackend(backend Backend) *Store {
 	return &Store{
-		prefs:   prefs,
+		backend: backend,
+		clock:   totp.SystemClock,
 		entries: make([]*Entry, 0),
 	}
 }
 
+// MigrateFrom は次回の読み込み時に、保存先に保存データがなければ移行する以前の保存先を設定する
+func (s *Store) MigrateFrom(legacy Backend) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.legacy = legacy
+}
+
+// Backend は保存先のBackendを返す
+func (s *Store) Backend() Backend {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend
+}
+
+// SwitchBackend は現在のエントリを新しいBackendに保存し、以降の保存先を切り替える
+// 新しいBackendへの保存が成功した場合のみ、元のBackendのスナップショットを引き継いで保存データを削除する
+// 切り替えに失敗した場合はerrを返し、切り替え後の引き継ぎや削除に失敗した場合は切り替えたままcleanupErrを返す
+// 隔離した保存データは元の場所に残す（復旧モードで表示するパスは変わらない）
+func (s *Store) SwitchBackend(backend Backend) (cleanupErr error, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.backend
+	s.backend = backend
+	if err := s.save(); err != nil {
+		s.backend = previous
+		return nil, err
+	}
+
+	if from, ok := previous.(SnapshotBackend); ok {
+		if to, ok := backend.(SnapshotImporter); ok {
+			cleanupErr = to.ImportSnapshots(from)
+		}
+	}
+	if err := previous.Clear(); err != nil {
+		cleanupErr = errors.Join(cleanupErr, err)
+	}
+	return cleanupErr, nil
+}
+
+// Clock はコード生成に使用するClockを返す
+func (s *Store) Clock() totp.Clock {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clock
+}
+
+// SetClock はコード生成に使用するClockを設定する（時刻オフセットの補正やテストに使用する）
+func (s *Store) SetClock(clock totp.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock
+}
+
 // Load は保存されたTOTPエントリを読み込む
+// 保存データを読み込めない場合や以前の保存先から移行できない場合は、空のストアとして復旧モードにする
 func (s *Store) Load() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
+
+	// 成功・失敗にかかわらずエントリ全体が置き換わる
+	s.emit(EventReloaded)
+
+	// 以前の保存先から移行できない場合は、移行元のデータを残したまま復旧モードにする
+	if s.legacy != nil {
+		if _, err := MigrateBackend(s.legacy, s.backend); err != nil {
+			s.enterRecovery(nil, err)
+			return err
+		}
+		s.legacy = nil
+	}
 
 	// 暗号化されたデータを取得
-	encrypted := s.prefs.GetTOTPData()
-	if encrypted == "" {
+	data, err := s.backend.Load()
+	if err != nil {
+		s.enterRecovery(nil, err)
+		return err
+	}
+	if len(data) == 0 {
 		s.entries = make([]*Entry, 0)
+		s.trash = nil
 		s.loaded = true
 		return nil
 	}
 
-	// Base64デコード
-	data, err := base64.StdEncoding.DecodeString(encrypted)
+	// 読み込めない場合は上書きしないよう復旧モードにする
+	decoded, err := decryptVault(data)
 	if err != nil {
-		return err
+		s.enterRecovery(data, err)
+		return fmt.Errorf("%w: %w", ErrVaultUnreadable, err)
 	}
 
+	s.entries = decoded.Entries
+	s.trash = decoded.Trash
+	s.loaded = true
+	s.failure = nil
+	return nil
+}
+
+// decryptVault は暗号化された保存データを復号してエントリとゴミ箱のエントリを返す
+func decryptVault(data []byte) (*vault, error) {
 	// マシンキー取得
 	key, err := machinekey.DeriveKey()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 復号
 	decrypted, err := crypto.Decrypt(string(key), data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// JSONデコード
-	var entries []*Entry
-	if err := json.Unmarshal(decrypted, &entries); err != nil {
+	// JSONデコード（過去のスキーマのデータは現在のスキーマに変換する）
+	return decodeVault(decrypted)
+}
+
+// Snapshots は保存データのスナップショットを新しい順に返す
+func (s *Store) Snapshots() ([]VaultSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	backend, ok := s.backend.(SnapshotBackend)
+	if !ok {
+		return nil, ErrSnapshotsUnsupported
+	}
+	return backend.Snapshots()
+}
+
+// SnapshotEntries は指定したスナップショットのエントリを復号して返す（復元前の確認用）
+func (s *Store) SnapshotEntries(id string) ([]*Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	backend, ok := s.backend.(SnapshotBackend)
+	if !ok {
+		return nil, ErrSnapshotsUnsupported
+	}
+	data, err := backend.LoadSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decryptVault(data)
+	if err != nil {
+		return nil, err
+	}
+	return decoded.Entries, nil
+}
+
+// RestoreSnapshot は指定したスナップショットのエントリを復元して保存する
+// 復元を取り消せるよう、現在の保存データのスナップショットを作成してから上書きする
+func (s *Store) RestoreSnapshot(id string) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	backend, ok := s.backend.(SnapshotBackend)
+	if !ok {
+		return ErrSnapshotsUnsupported
+	}
+	data, err := backend.LoadSnapshot(id)
+	if err != nil {
+		return err
+	}
+	decoded, err := decryptVault(data)
+	if err != nil {
+		return err
+	}
+	if err := backend.Snapshot(); err != nil {
 		return err
 	}
 
-	s.entries = entries
-	s.loaded = true
+	previous, previousTrash := s.entries, s.trash
+	s.entries, s.trash = decoded.Entries, decoded.Trash
+	if err := s.save(); err != nil {
+		s.entries, s.trash = previous, previousTrash
+		return err
+	}
+	s.emit(EventReloaded)
 	return nil
 }
 
@@ -75,8 +238,18 @@ func (s *Store) Save() error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// JSONエンコード
-	data, err := json.Marshal(s.entries)
+	return s.save()
+}
+
+// save は現在のTOTPエントリを暗号化して保存する（ロックは呼び出し側で取得する）
+func (s *Store) save() error {
+	// 読み込めなかった保存データを隔離できていない場合は上書きしない
+	if s.failure != nil && s.failure.QuarantinePath == "" {
+		return ErrVaultLocked
+	}
+
+	// 現在のスキーマでJSONエンコード
+	data, err := encodeVault(s.entries, s.trash)
 	if err != nil {
 		return err
 	}
@@ -93,12 +266,14 @@ func (s *Store) Save() error {
 		return err
 	}
 
-	// Base64エンコードして保存
-	s.prefs.SetTOTPData(base64.StdEncoding.EncodeToString(encrypted))
+	if err := s.backend.Save(encrypted); err != nil {
+		return err
+	}
+	s.usageDirty.Store(false)
 	return nil
 }
 
-// GetAll は全てのTOTPエントリを取得する（Order順でソート済み）
+// GetAll は全てのTOTPエントリを取得する（ピン留めしたエントリを先頭に、それぞれOrder順でソート済み）
 func (s *Store) GetAll() []*Entry {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -106,8 +281,11 @@ func (s *Store) GetAll() []*Entry {
 	// コピーを返す
 	result := slices.Clone(s.entries)
 
-	// Order順でソート
-	sort.Slice(result, func(i, j int) bool {
+	// ピン留め → Order順でソート
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Pinned != result[j].Pinned {
+			return result[i].Pinned
+		}
 		return result[i].Order < result[j].Order
 	})
 
@@ -130,8 +308,15 @@ func (s *Store) Get(id string) (*Entry, error) {
 // Add は新しいエントリを追加する
 func (s *Store) Add(entry *Entry) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
+
+	s.add(entry)
+	s.emit(EventAdded, entry.ID)
+	return nil
+}
 
+// add はエントリを末尾のOrder番号で追加する（ロックは呼び出し側で取得する）
+func (s *Store) add(entry *Entry) {
 	// 次のOrder番号を設定
 	maxOrder := -1
 	for _, e := range s.entries {
@@ -142,31 +327,136 @@ func (s *Store) Add(entry *Entry) error {
 	entry.Order = maxOrder + 1
 
 	s.entries = append(s.entries, entry)
-	return nil
 }
 
 // Update は既存のエントリを更新する
 func (s *Store) Update(entry *Entry) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
 
+	return s.update(entry)
+}
+
+// update は既存のエントリを更新する（ロックは呼び出し側で取得する）
+func (s *Store) update(entry *Entry) error {
 	for i, e := range s.entries {
 		if e.ID == entry.ID {
 			s.entries[i] = entry
+			s.emit(EventUpdated, entry.ID)
 			return nil
 		}
 	}
 	return ErrEntryNotFound
 }
 
-// Delete は指定したIDのエントリを削除する
+// Delete は指定したIDのエントリをゴミ箱に移動する（ゴミ箱から復元できる）
 func (s *Store) Delete(id string) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
 
+	return s.delete(id)
+}
+
+// delete は指定したIDのエントリをゴミ箱に移動する（ゴミ箱に同じIDのエントリがある場合は置き換える。ロックは呼び出し側で取得する）
+func (s *Store) delete(id string) error {
 	for i, entry := range s.entries {
 		if entry.ID == id {
 			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			entry.DeletedAt = s.clock.Now()
+			// 置き換えインポートの繰り返しなどで同じIDのエントリが再び削除された場合は、
+			// ゴミ箱の古いエントリを置き換えてIDでゴミ箱のエントリを一意に指定できるようにする
+			s.trash = slices.DeleteFunc(s.trash, func(e *Entry) bool { return e.ID == id })
+			s.trash = append(s.trash, entry)
+			s.emit(EventDeleted, id)
+			return nil
+		}
+	}
+	return ErrEntryNotFound
+}
+
+// NextHOTP はHOTPエントリの現在のカウンター値でコードを生成し、カウンターを進めて保存する
+// 保存に失敗した場合はカウンターを元に戻し、同じコードが再発行されるようにする
+func (s *Store) NextHOTP(id string) (string, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	index := slices.IndexFunc(s.entries, func(e *Entry) bool {
+		return e.ID == id
+	})
+	if index < 0 {
+		return "", ErrEntryNotFound
+	}
+
+	entry := s.entries[index]
+	if !entry.IsHOTP() {
+		return "", ErrNotHOTP
+	}
+
+	code, err := entry.HOTP()
+	if err != nil {
+		return "", err
+	}
+
+	entry.Counter++
+	if err := s.save(); err != nil {
+		entry.Counter--
+		return "", err
+	}
+	s.emit(EventUpdated, id)
+	return code, nil
+}
+
+// RecordUse は指定したIDのエントリの使用履歴（最終使用日時と使用回数）をStoreのClockの時刻で記録する
+// 記録はすぐには保存せず、次の保存またはusageFlushDelay後のFlushUsageでまとめて保存する
+func (s *Store) RecordUse(id string) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	index := slices.IndexFunc(s.entries, func(e *Entry) bool {
+		return e.ID == id
+	})
+	if index < 0 {
+		return ErrEntryNotFound
+	}
+
+	entry := s.entries[index]
+	entry.LastUsedAt = s.clock.Now()
+	entry.UseCount++
+	s.usageDirty.Store(true)
+	if s.usageTimer == nil {
+		s.usageTimer = time.AfterFunc(usageFlushDelay, func() {
+			_ = s.FlushUsage()
+		})
+	}
+	s.emit(EventUpdated, id)
+	return nil
+}
+
+// FlushUsage は保存していない使用履歴がある場合に保存する（アプリの終了時などに呼び出す）
+// 保存に失敗した場合は使用履歴を残し、次の保存で再び保存する
+func (s *Store) FlushUsage() error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if s.usageTimer != nil {
+		s.usageTimer.Stop()
+		s.usageTimer = nil
+	}
+	if !s.usageDirty.Load() {
+		return nil
+	}
+	return s.save()
+}
+
+// SetPinned は指定したIDのエントリのピン留めを設定する
+func (s *Store) SetPinned(id string, pinned bool) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	for _, entry := range s.entries {
+		if entry.ID == id {
+			entry.Pinned = pinned
+			s.emit(EventUpdated, id)
 			return nil
 		}
 	}
@@ -176,7 +466,7 @@ func (s *Store) Delete(id string) error {
 // Reorder はエントリの順序を更新する
 func (s *Store) Reorder(ids []string) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	defer s.unlock()
 
 	// IDからエントリを検索してOrder更新
 	for order, id := range ids {
@@ -187,9 +477,17 @@ func (s *Store) Reorder(ids []string) error {
 			}
 		}
 	}
+	s.emit(EventReordered, ids...)
 	return nil
 }
 
+// Tags は全エントリのタグを上位の階層を含めて名前順で返す
+func (s *Store) Tags() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return collectTags(s.entries)
+}
+
 // Count はエントリ数を返す
 func (s *Store) Count() int {
 	s.mu.RLock()
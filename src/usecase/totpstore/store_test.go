@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/nktmys/winticator/src/pkg/totp"
 	"github.com/nktmys/winticator/src/usecase/preferences"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -367,3 +368,196 @@ func TestStore_EmptyLoad(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, store.Count())
 }
+
+func TestStore_NextHOTP(t *testing.T) {
+	machinekey.ResetCache()
+
+	mock := newMockPreferences()
+	store := New(preferences.New(mock))
+	err := store.Load()
+	require.NoError(t, err)
+
+	err = store.Add(&Entry{
+		ID:        "hotp-id",
+		Issuer:    "VPN",
+		Account:   "user",
+		Type:      TypeHOTP,
+		Secret:    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Algorithm: "SHA1",
+		Digits:    6,
+	})
+	require.NoError(t, err)
+
+	// RFC 4226 テストベクター（カウンター=0, 1）
+	code, err := store.NextHOTP("hotp-id")
+	require.NoError(t, err)
+	assert.Equal(t, "755224", code)
+
+	code, err = store.NextHOTP("hotp-id")
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	// 押下のたびにカウンターが保存されていること
+	store2 := New(preferences.New(mock))
+	err = store2.Load()
+	require.NoError(t, err)
+	got, err := store2.Get("hotp-id")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.Counter)
+}
+
+func TestStore_NextHOTP_Errors(t *testing.T) {
+	machinekey.ResetCache()
+
+	store := New(preferences.New(newMockPreferences()))
+	err := store.Load()
+	require.NoError(t, err)
+
+	err = store.Add(&Entry{ID: "totp-id", Type: TypeTOTP, Secret: "JBSWY3DPEHPK3PXP"})
+	require.NoError(t, err)
+
+	_, err = store.NextHOTP("totp-id")
+	require.ErrorIs(t, err, ErrNotHOTP)
+
+	_, err = store.NextHOTP("nonexistent")
+	require.ErrorIs(t, err, ErrEntryNotFound)
+}
+
+func TestStore_Clock(t *testing.T) {
+	store := New(preferences.New(newMockPreferences()))
+	assert.Equal(t, totp.SystemClock, store.Clock())
+
+	clock := totp.ClockFunc(func() time.Time { return time.Unix(59, 0) })
+	store.SetClock(clock)
+	assert.Equal(t, time.Unix(59, 0), store.Clock().Now())
+}
+
+func TestStore_SaveAndLoad_Notes(t *testing.T) {
+	machinekey.ResetCache()
+
+	mock := newMockPreferences()
+	store := New(preferences.New(mock))
+	require.NoError(t, store.Load())
+
+	notes := "Owner: platform team\nRecovery: ops@example.com\nhttps://tickets.example.com/123"
+	entry := NewEntry("GitHub", "myaccount", "JBSWY3DPEHPK3PXP")
+	entry.Notes = notes
+	require.NoError(t, store.Add(entry))
+	require.NoError(t, store.Save())
+
+	// メモは暗号化されたデータに含まれ、平文では保存されない
+	assert.NotContains(t, mock.data["totpData"], "platform team")
+
+	store2 := New(preferences.New(mock))
+	require.NoError(t, store2.Load())
+	got, err := store2.Get(entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, notes, got.Notes)
+
+	// メモはotpauth URIに含めない
+	assert.NotContains(t, got.ToOTPAuthURI(), "platform")
+}
+
+func TestStore_GetAll_PinnedFirst(t *testing.T) {
+	machinekey.ResetCache()
+
+	prefs := preferences.New(newMockPreferences())
+	store := New(prefs)
+	err := store.Load()
+	require.NoError(t, err)
+
+	for _, id := range []string{"id-a", "id-b", "id-c", "id-d"} {
+		require.NoError(t, store.Add(&Entry{ID: id, Issuer: "Test", Secret: "SECRET"}))
+	}
+
+	// ピン留めしたエントリはOrderに関わらず先頭に並ぶ
+	require.NoError(t, store.SetPinned("id-d", true))
+	require.NoError(t, store.SetPinned("id-b", true))
+
+	ids := func() []string {
+		var ids []string
+		for _, e := range store.GetAll() {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"id-b", "id-d", "id-a", "id-c"}, ids())
+
+	// セクション内の順序はOrderに従う
+	require.NoError(t, store.Reorder([]string{"id-d", "id-b", "id-c", "id-a"}))
+	assert.Equal(t, []string{"id-d", "id-b", "id-c", "id-a"}, ids())
+
+	// ピン留めの解除と保存・読み込み
+	require.NoError(t, store.SetPinned("id-d", false))
+	require.NoError(t, store.Save())
+
+	loaded := New(prefs)
+	require.NoError(t, loaded.Load())
+	var loadedIDs []string
+	for _, e := range loaded.GetAll() {
+		loadedIDs = append(loadedIDs, e.ID)
+	}
+	assert.Equal(t, []string{"id-b", "id-d", "id-c", "id-a"}, loadedIDs)
+
+	assert.ErrorIs(t, store.SetPinned("missing", true), ErrEntryNotFound)
+}
+
+func TestStore_RecordUse(t *testing.T) {
+	machinekey.ResetCache()
+
+	prefs := preferences.New(newMockPreferences())
+	store := New(prefs)
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	store.SetClock(totp.ClockFunc(func() time.Time { return now }))
+	require.NoError(t, store.Load())
+	require.NoError(t, store.Add(&Entry{ID: "id-1", Issuer: "Test", Secret: "SECRET"}))
+	require.NoError(t, store.Save())
+
+	// StoreのClockの時刻で記録する
+	require.NoError(t, store.RecordUse("id-1"))
+	now = now.Add(time.Hour)
+	require.NoError(t, store.RecordUse("id-1"))
+	entry, err := store.Get("id-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, entry.UseCount)
+	assert.True(t, now.Equal(entry.LastUsedAt))
+
+	// 記録しただけでは保存しない
+	loaded := New(prefs)
+	require.NoError(t, loaded.Load())
+	entry, err = loaded.Get("id-1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, entry.UseCount)
+
+	// FlushUsageで保存する
+	require.NoError(t, store.FlushUsage())
+	loaded = New(prefs)
+	require.NoError(t, loaded.Load())
+	entry, err = loaded.Get("id-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, entry.UseCount)
+	assert.True(t, now.Equal(entry.LastUsedAt))
+
+	assert.ErrorIs(t, store.RecordUse("missing"), ErrEntryNotFound)
+}
+
+func TestStore_FlushUsage(t *testing.T) {
+	store, backend, existing := newBatchTestStore(t)
+
+	// 保存に失敗した場合は使用履歴を残し、次の保存で保存する
+	require.NoError(t, store.RecordUse(existing[0].ID))
+	backend.fail = true
+	assert.ErrorIs(t, store.FlushUsage(), errSaveFailed)
+	assert.True(t, store.usageDirty.Load())
+
+	backend.fail = false
+	require.NoError(t, store.Save())
+	assert.False(t, store.usageDirty.Load())
+	assert.NoError(t, store.FlushUsage())
+
+	loaded := NewWithBackend(backend)
+	require.NoError(t, loaded.Load())
+	entry, err := loaded.Get(existing[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, entry.UseCount)
+}
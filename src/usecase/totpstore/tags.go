@@ -0,0 +1,82 @@
+package totpstore
+
+import (
+	"slices"
+	"strings"
+)
+
+// TagSeparator は階層化したタグ（フォルダ）の区切り文字（例: "work/production"）
+const TagSeparator = "/"
+
+// ParseTags はカンマ区切りの文字列をタグの一覧に変換する
+func ParseTags(text string) []string {
+	return NormalizeTags(strings.Split(text, ","))
+}
+
+// FormatTags はタグの一覧をカンマ区切りの文字列に変換する
+func FormatTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+// NormalizeTags はタグの前後の空白と区切り文字を除去し、空のタグと重複（大文字小文字は区別しない）を取り除く
+func NormalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if slices.ContainsFunc(result, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+		result = append(result, tag)
+	}
+	return result
+}
+
+// normalizeTag は階層ごとの前後の空白を除去し、空の階層を取り除く
+func normalizeTag(tag string) string {
+	var parts []string
+	for part := range strings.SplitSeq(tag, TagSeparator) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, TagSeparator)
+}
+
+// SetTags はタグを正規化して設定する
+func (e *Entry) SetTags(tags []string) {
+	e.Tags = NormalizeTags(tags)
+}
+
+// HasTag はエントリがtagまたはその下位のタグを持つかどうかを返す（大文字小文字は区別しない）
+// 例: "work" は "work" と "work/production" のどちらにも一致する
+func (e *Entry) HasTag(tag string) bool {
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return false
+	}
+	prefix := strings.ToLower(tag) + TagSeparator
+	return slices.ContainsFunc(e.Tags, func(t string) bool {
+		return strings.EqualFold(t, tag) || strings.HasPrefix(strings.ToLower(t), prefix)
+	})
+}
+
+// collectTags はエントリのタグと上位の階層を重複なく名前順で返す
+func collectTags(entries []*Entry) []string {
+	var tags []string
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			parts := strings.Split(tag, TagSeparator)
+			for i := range parts {
+				tags = append(tags, strings.Join(parts[:i+1], TagSeparator))
+			}
+		}
+	}
+	tags = NormalizeTags(tags)
+	slices.SortFunc(tags, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	return tags
+}
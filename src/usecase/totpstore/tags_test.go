@@ -0,0 +1,68 @@
+package totpstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"work", []string{"work"}},
+		{" work , personal ", []string{"work", "personal"}},
+		{"work,,Work,WORK", []string{"work"}},
+		{" work / production /, /staging/", []string{"work/production", "staging"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ParseTags(tt.input), "input=%q", tt.input)
+	}
+}
+
+func TestEntryHasTag(t *testing.T) {
+	entry := NewEntry("Test", "user", "JBSWY3DPEHPK3PXP")
+	entry.SetTags([]string{"Work/Production", "personal"})
+
+	assert.True(t, entry.HasTag("work/production"))
+	assert.True(t, entry.HasTag("Work"))
+	assert.True(t, entry.HasTag("PERSONAL"))
+	assert.False(t, entry.HasTag("work/staging"))
+	assert.False(t, entry.HasTag("Wor"))
+	assert.False(t, entry.HasTag(""))
+}
+
+func TestParseOTPAuthURI_Tags(t *testing.T) {
+	uri := "otpauth://totp/Test:user?secret=JBSWY3DPEHPK3PXP&tags=work%2Fproduction%2Cpersonal"
+	entry, err := ParseOTPAuthURI(uri)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work/production", "personal"}, entry.Tags)
+
+	// タグは元のURIのまま出力する
+	assert.Equal(t, uri, entry.ToOTPAuthURI())
+
+	// タグを変更すると再構築したURIに反映される
+	entry.SetTags([]string{"staging"})
+	parsed, err := ParseOTPAuthURI(entry.ToOTPAuthURI())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"staging"}, parsed.Tags)
+
+	// タグがなければtagsパラメータを出力しない
+	entry.SetTags(nil)
+	assert.NotContains(t, entry.ToOTPAuthURI(), "tags=")
+}
+
+func TestStore_Tags(t *testing.T) {
+	store := New(nil)
+	store.entries = []*Entry{
+		{ID: "a", Tags: []string{"work/production", "personal"}},
+		{ID: "b", Tags: []string{"Work/staging"}},
+		{ID: "c"},
+	}
+
+	// 上位の階層を含め、重複なく名前順で返す
+	assert.Equal(t, []string{"personal", "work", "work/production", "Work/staging"}, store.Tags())
+}
@@ -0,0 +1,109 @@
+package totpstore
+
+import (
+	"slices"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// Trash はゴミ箱のエントリをゴミ箱に移動した日時の新しい順に返す
+func (s *Store) Trash() []*Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := slices.Clone(s.trash)
+	slices.SortStableFunc(result, func(a, b *Entry) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return result
+}
+
+// TrashCount はゴミ箱のエントリ数を返す
+func (s *Store) TrashCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.trash)
+}
+
+// RestoreFromTrash は指定したIDのエントリをゴミ箱から戻す（リストの末尾に追加する）
+// ゴミ箱に移動した後に同じIDのエントリが追加されている場合は新しいIDを割り当てる
+func (s *Store) RestoreFromTrash(id string) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	index := s.trashIndex(id)
+	if index < 0 {
+		return ErrEntryNotFound
+	}
+
+	entry := s.trash[index]
+	s.trash = slices.Delete(s.trash, index, index+1)
+	entry.DeletedAt = time.Time{}
+	if slices.ContainsFunc(s.entries, func(e *Entry) bool { return e.ID == entry.ID }) {
+		entry.ID = xid.New().String()
+	}
+	s.add(entry)
+	s.emit(EventAdded, entry.ID)
+	return nil
+}
+
+// Purge は指定したIDのエントリをゴミ箱から完全に削除する
+func (s *Store) Purge(id string) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	index := s.trashIndex(id)
+	if index < 0 {
+		return ErrEntryNotFound
+	}
+	s.trash = slices.Delete(s.trash, index, index+1)
+	s.emit(EventPurged, id)
+	return nil
+}
+
+// EmptyTrash はゴミ箱のエントリをすべて完全に削除し、削除した件数を返す
+func (s *Store) EmptyTrash() int {
+	s.mu.Lock()
+	defer s.unlock()
+
+	return s.purgeFunc(func(*Entry) bool { return true })
+}
+
+// PurgeExpired はゴミ箱に移動してからretentionを超えたエントリを完全に削除し、削除した件数を返す
+// retentionが0以下の場合は削除しない
+func (s *Store) PurgeExpired(retention time.Duration) int {
+	s.mu.Lock()
+	defer s.unlock()
+
+	if retention <= 0 {
+		return 0
+	}
+	expiry := s.clock.Now().Add(-retention)
+	return s.purgeFunc(func(e *Entry) bool {
+		return e.DeletedAt.Before(expiry)
+	})
+}
+
+// purgeFunc は条件に一致するゴミ箱のエントリを完全に削除し、削除した件数を返す（ロックは呼び出し側で取得する）
+func (s *Store) purgeFunc(match func(*Entry) bool) int {
+	var ids []string
+	s.trash = slices.DeleteFunc(s.trash, func(e *Entry) bool {
+		if !match(e) {
+			return false
+		}
+		ids = append(ids, e.ID)
+		return true
+	})
+	if len(ids) > 0 {
+		s.emit(EventPurged, ids...)
+	}
+	return len(ids)
+}
+
+// trashIndex は指定したIDのゴミ箱のエントリの位置を返す（見つからない場合は-1）
+func (s *Store) trashIndex(id string) int {
+	return slices.IndexFunc(s.trash, func(e *Entry) bool {
+		return e.ID == id
+	})
+}
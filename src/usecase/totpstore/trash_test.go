@@ -0,0 +1,196 @@
+package totpstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/nktmys/winticator/src/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTrashTestStore は固定した時刻を返すClockを設定したテスト用のStoreを作成する
+func newTrashTestStore(t *testing.T, now *time.Time) *Store {
+	t.Helper()
+	store := NewWithBackend(NewMemoryBackend())
+	store.SetClock(totp.ClockFunc(func() time.Time { return *now }))
+	require.NoError(t, store.Load())
+	return store
+}
+
+func TestStore_Delete_MovesToTrash(t *testing.T) {
+	machinekey.ResetCache()
+
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	store := newTrashTestStore(t, &now)
+	entry := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+	require.NoError(t, store.Add(entry))
+	require.NoError(t, store.Delete(entry.ID))
+
+	// 通常の一覧からは除外される
+	assert.Empty(t, store.GetAll())
+	assert.Equal(t, 0, store.Count())
+	_, err := store.Get(entry.ID)
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+
+	trash := store.Trash()
+	require.Len(t, trash, 1)
+	assert.Equal(t, entry.ID, trash[0].ID)
+	assert.True(t, now.Equal(trash[0].DeletedAt))
+	assert.Equal(t, 1, store.TrashCount())
+
+	// ゴミ箱は保存され、読み込み直しても残る
+	require.NoError(t, store.Save())
+	loaded := NewWithBackend(store.Backend())
+	require.NoError(t, loaded.Load())
+	assert.Equal(t, 0, loaded.Count())
+	assert.Equal(t, 1, loaded.TrashCount())
+}
+
+func TestStore_Trash_NewestFirst(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	store := newTrashTestStore(t, &now)
+	first := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+	second := NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ")
+	require.NoError(t, store.Add(first))
+	require.NoError(t, store.Add(second))
+
+	require.NoError(t, store.Delete(first.ID))
+	now = now.Add(time.Hour)
+	require.NoError(t, store.Delete(second.ID))
+
+	trash := store.Trash()
+	require.Len(t, trash, 2)
+	assert.Equal(t, second.ID, trash[0].ID)
+	assert.Equal(t, first.ID, trash[1].ID)
+}
+
+func TestStore_RestoreFromTrash(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("restore", func(t *testing.T) {
+		store := newTrashTestStore(t, &now)
+		entry := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+		other := NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ")
+		require.NoError(t, store.Add(entry))
+		require.NoError(t, store.Add(other))
+		require.NoError(t, store.Delete(entry.ID))
+
+		require.NoError(t, store.RestoreFromTrash(entry.ID))
+		assert.Equal(t, 0, store.TrashCount())
+
+		// 末尾に戻り、削除日時はクリアされる
+		all := store.GetAll()
+		require.Len(t, all, 2)
+		assert.Equal(t, entry.ID, all[1].ID)
+		assert.True(t, all[1].DeletedAt.IsZero())
+	})
+
+	t.Run("id conflict", func(t *testing.T) {
+		store := newTrashTestStore(t, &now)
+		entry := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+		require.NoError(t, store.Add(entry))
+		require.NoError(t, store.Delete(entry.ID))
+
+		// ゴミ箱に移動した後に同じIDのエントリを追加した場合
+		readded := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+		readded.ID = entry.ID
+		require.NoError(t, store.Add(readded))
+
+		require.NoError(t, store.RestoreFromTrash(entry.ID))
+		all := store.GetAll()
+		require.Len(t, all, 2)
+		assert.NotEqual(t, all[0].ID, all[1].ID)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		store := newTrashTestStore(t, &now)
+		assert.ErrorIs(t, store.RestoreFromTrash("missing"), ErrEntryNotFound)
+	})
+}
+
+func TestStore_Delete_ReplacesTrashWithSameID(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	store := newTrashTestStore(t, &now)
+	entry := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+	require.NoError(t, store.Add(entry))
+
+	// 置き換えインポートを繰り返した場合のように、同じIDのエントリを再び削除する
+	for range 2 {
+		require.NoError(t, store.Batch(func(tx *Tx) error {
+			tx.DeleteAll()
+			imported := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+			imported.ID = entry.ID
+			tx.Add(imported)
+			return nil
+		}))
+		now = now.Add(time.Hour)
+	}
+
+	// ゴミ箱には最後に削除したエントリのみが残り、IDで一意に指定できる
+	trash := store.Trash()
+	require.Len(t, trash, 1)
+	assert.True(t, time.Date(2025, 7, 1, 11, 0, 0, 0, time.UTC).Equal(trash[0].DeletedAt))
+	require.NoError(t, store.Purge(entry.ID))
+	assert.Equal(t, 0, store.TrashCount())
+}
+
+func TestStore_Purge(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	store := newTrashTestStore(t, &now)
+	first := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+	second := NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ")
+	require.NoError(t, store.Add(first))
+	require.NoError(t, store.Add(second))
+	require.NoError(t, store.Delete(first.ID))
+	require.NoError(t, store.Delete(second.ID))
+
+	require.NoError(t, store.Purge(first.ID))
+	assert.ErrorIs(t, store.Purge(first.ID), ErrEntryNotFound)
+	assert.Equal(t, 1, store.TrashCount())
+
+	assert.Equal(t, 1, store.EmptyTrash())
+	assert.Equal(t, 0, store.TrashCount())
+}
+
+func TestStore_PurgeExpired(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	store := newTrashTestStore(t, &now)
+	old := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+	recent := NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ")
+	require.NoError(t, store.Add(old))
+	require.NoError(t, store.Add(recent))
+	require.NoError(t, store.Delete(old.ID))
+	now = now.Add(20 * 24 * time.Hour)
+	require.NoError(t, store.Delete(recent.ID))
+	now = now.Add(15 * 24 * time.Hour)
+
+	events := recordEvents(t, store)
+
+	// 0以下の場合は削除しない
+	assert.Equal(t, 0, store.PurgeExpired(0))
+	assert.Equal(t, 2, store.TrashCount())
+
+	assert.Equal(t, 1, store.PurgeExpired(30*24*time.Hour))
+	trash := store.Trash()
+	require.Len(t, trash, 1)
+	assert.Equal(t, recent.ID, trash[0].ID)
+	assert.Equal(t, []Event{{Type: EventPurged, IDs: []string{old.ID}}}, *events)
+}
+
+func TestEncodeVault_ExcludesTrash(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	store := newTrashTestStore(t, &now)
+	kept := NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")
+	deleted := NewEntry("Google", "user", "GEZDGNBVGY3TQOJQ")
+	require.NoError(t, store.Add(kept))
+	require.NoError(t, store.Add(deleted))
+	require.NoError(t, store.Delete(deleted.ID))
+
+	// エクスポートは通常の一覧のエントリのみを含む
+	data, err := EncodeVault(store.GetAll())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), deleted.ID)
+	assert.NotContains(t, string(data), `"trash"`)
+}
@@ -0,0 +1,141 @@
+package totpstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// SchemaVersion は現在の保存データ（ボールト・バックアップ）のスキーマバージョン
+//
+// スキーマの履歴:
+//   - 1: Entryの配列をそのまま保存する（バージョン番号なし）
+//   - 2: バージョン番号を持つエンベロープ {"version": 2, "entries": [...]} で保存する
+//   - 3: ゴミ箱のエントリを "trash" に保存する（古いバージョンで保存してゴミ箱が失われないようにバージョンを上げる）
+const SchemaVersion = 3
+
+// vault は保存データのエンベロープ
+type vault struct {
+	Version int      `json:"version"`         // スキーマバージョン
+	Entries []*Entry `json:"entries"`         // TOTPエントリ
+	Trash   []*Entry `json:"trash,omitempty"` // ゴミ箱のエントリ（バックアップには含めない）
+}
+
+// vaultMigration は1つ前のスキーマのデータを次のスキーマのデータに変換する
+type vaultMigration func(data []byte) ([]byte, error)
+
+// vaultMigrations はスキーマの移行処理（vaultMigrations[i]はバージョンi+1からi+2への変換）
+// スキーマを変更する場合はSchemaVersionを上げて末尾に移行処理を追加する
+var vaultMigrations = []vaultMigration{
+	migrateVaultV1ToV2,
+	migrateVaultV2ToV3,
+}
+
+// EncodeVault はエントリを現在のスキーマの保存データにエンコードする
+func EncodeVault(entries []*Entry) ([]byte, error) {
+	return encodeVault(entries, nil)
+}
+
+// DecodeVault は保存データをデコードしてエントリを返す（ゴミ箱のエントリは含まない）
+// 過去のスキーマのデータは移行処理を順に適用して現在のスキーマに変換する
+func DecodeVault(data []byte) ([]*Entry, error) {
+	decoded, err := decodeVault(data)
+	if err != nil {
+		return nil, err
+	}
+	return decoded.Entries, nil
+}
+
+// encodeVault はエントリとゴミ箱のエントリを現在のスキーマの保存データにエンコードする
+func encodeVault(entries, trash []*Entry) ([]byte, error) {
+	if entries == nil {
+		entries = make([]*Entry, 0)
+	}
+	return json.Marshal(vault{Version: SchemaVersion, Entries: entries, Trash: trash})
+}
+
+// decodeVault は保存データをデコードしてエントリとゴミ箱のエントリを返す
+func decodeVault(data []byte) (*vault, error) {
+	version, err := vaultVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedSchema, version)
+	}
+
+	for v := version; v < SchemaVersion; v++ {
+		if data, err = vaultMigrations[v-1](data); err != nil {
+			return nil, fmt.Errorf("%w: migrating from version %d: %w", ErrInvalidVault, v, err)
+		}
+	}
+
+	var decoded vault
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidVault, err)
+	}
+	if decoded.Entries == nil {
+		decoded.Entries = make([]*Entry, 0)
+	}
+
+	// アイコンのキーは保存しないため、読み込み時に求める
+	for _, entry := range slices.Concat(decoded.Entries, decoded.Trash) {
+		if entry.HasIcon() {
+			entry.iconKey = iconKey(entry.Icon)
+		}
+	}
+	return &decoded, nil
+}
+
+// vaultVersion は保存データのスキーマバージョンを返す（配列の場合はバージョン1）
+func vaultVersion(data []byte) (int, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		return 1, nil
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidVault, err)
+	}
+	if header.Version < 2 {
+		return 0, fmt.Errorf("%w: invalid version %d", ErrInvalidVault, header.Version)
+	}
+	return header.Version, nil
+}
+
+// migrateVaultV1ToV2 はEntryの配列をエンベロープで包む
+// 種別が記録されていない（HOTP対応前の）エントリはTOTPとして明示する
+func migrateVaultV1ToV2(data []byte) ([]byte, error) {
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if t, ok := entry["type"]; !ok || string(t) == `""` || string(t) == "null" {
+			entry["type"] = json.RawMessage(`"` + TypeTOTP + `"`)
+		}
+	}
+
+	if entries == nil {
+		entries = make([]map[string]json.RawMessage, 0)
+	}
+	return json.Marshal(map[string]any{
+		"version": 2,
+		"entries": entries,
+	})
+}
+
+// migrateVaultV2ToV3 はバージョン番号を上げる（ゴミ箱は空として扱う）
+func migrateVaultV2ToV3(data []byte) ([]byte, error) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	envelope["version"] = json.RawMessage("3")
+	return json.Marshal(envelope)
+}
@@ -0,0 +1,143 @@
+package totpstore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nktmys/winticator/src/pkg/machinekey"
+	"github.com/nktmys/winticator/src/pkg/totp"
+	"github.com/nktmys/winticator/src/usecase/crypto"
+	"github.com/nktmys/winticator/src/usecase/preferences"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadVaultFixture はスキーマバージョンごとの保存データのフィクスチャを読み込む
+func loadVaultFixture(t *testing.T, version int) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "vault", fmt.Sprintf("v%d.json", version)))
+	require.NoError(t, err)
+	return data
+}
+
+func TestVaultMigrations_CoverAllVersions(t *testing.T) {
+	// スキーマバージョンごとに移行処理が1つずつ定義されていること
+	assert.Len(t, vaultMigrations, SchemaVersion-1)
+}
+
+func TestDecodeVault_Fixtures(t *testing.T) {
+	// 過去のすべてのスキーマのデータを現在のスキーマとして読み込めること
+	for version := 1; version <= SchemaVersion; version++ {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			entries, err := DecodeVault(loadVaultFixture(t, version))
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+
+			totpEntry := entries[0]
+			assert.Equal(t, "GitHub", totpEntry.Issuer)
+			assert.Equal(t, "user@example.com", totpEntry.Account)
+			assert.Equal(t, TypeTOTP, totpEntry.Type)
+			assert.Equal(t, totp.AlgorithmSHA1, totpEntry.Algorithm)
+			assert.Equal(t, 6, totpEntry.Digits)
+			assert.Equal(t, 30, totpEntry.Period)
+			assert.True(t, time.Date(2025, 1, 15, 9, 30, 0, 0, time.UTC).Equal(totpEntry.CreatedAt))
+			require.NoError(t, totpEntry.Validate())
+
+			hotpEntry := entries[1]
+			assert.Equal(t, TypeHOTP, hotpEntry.Type)
+			assert.Equal(t, totp.AlgorithmSHA256, hotpEntry.Algorithm)
+			assert.Equal(t, 8, hotpEntry.Digits)
+			// 大きなカウンター値も精度を失わずに移行すること
+			assert.Equal(t, uint64(9007199254740993), hotpEntry.Counter)
+			assert.Equal(t, []string{"work/production"}, hotpEntry.Tags)
+			assert.Equal(t, "recovery: ops@example.com", hotpEntry.Notes)
+			assert.True(t, hotpEntry.Pinned)
+			assert.Equal(t, 1, hotpEntry.Order)
+			require.NoError(t, hotpEntry.Validate())
+		})
+	}
+}
+
+func TestEncodeVault_RoundTrip(t *testing.T) {
+	entries := []*Entry{NewEntry("GitHub", "user", "JBSWY3DPEHPK3PXP")}
+
+	data, err := EncodeVault(entries)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), fmt.Sprintf(`"version":%d`, SchemaVersion))
+
+	decoded, err := DecodeVault(data)
+	require.NoError(t, err)
+	require.Len(t, decoded, 1)
+	assert.Equal(t, entries[0].ID, decoded[0].ID)
+
+	// 空のエントリも配列として保存する
+	data, err = EncodeVault(nil)
+	require.NoError(t, err)
+	decoded, err = DecodeVault(data)
+	require.NoError(t, err)
+	assert.Empty(t, decoded)
+}
+
+func TestDecodeVault_Errors(t *testing.T) {
+	_, err := DecodeVault([]byte(`{"version": 99, "entries": []}`))
+	assert.ErrorIs(t, err, ErrUnsupportedSchema)
+
+	_, err = DecodeVault([]byte(`{"entries": []}`))
+	assert.ErrorIs(t, err, ErrInvalidVault)
+
+	_, err = DecodeVault([]byte(`not json`))
+	assert.ErrorIs(t, err, ErrInvalidVault)
+
+	_, err = DecodeVault([]byte(`[{"id": 1}]`))
+	assert.ErrorIs(t, err, ErrInvalidVault)
+}
+
+func TestStore_Load_UpgradesV1(t *testing.T) {
+	machinekey.ResetCache()
+
+	// バージョン番号なしで保存された既存のデータを暗号化して保存する
+	key, err := machinekey.DeriveKey()
+	require.NoError(t, err)
+	encrypted, err := crypto.Encrypt(string(key), loadVaultFixture(t, 1))
+	require.NoError(t, err)
+
+	prefs := preferences.New(newMockPreferences())
+	prefs.SetTOTPData(base64.StdEncoding.EncodeToString(encrypted))
+
+	store := New(prefs)
+	require.NoError(t, store.Load())
+	assert.Equal(t, 2, store.Count())
+
+	// 保存すると現在のスキーマで書き込まれる
+	require.NoError(t, store.Save())
+	data, err := base64.StdEncoding.DecodeString(prefs.GetTOTPData())
+	require.NoError(t, err)
+	decrypted, err := crypto.Decrypt(string(key), data)
+	require.NoError(t, err)
+	version, err := vaultVersion(decrypted)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, version)
+}
+
+func TestDecodeVault_Trash(t *testing.T) {
+	// ゴミ箱のエントリは保存データから読み込むが、DecodeVaultの結果には含めない
+	decoded, err := decodeVault(loadVaultFixture(t, 3))
+	require.NoError(t, err)
+	require.Len(t, decoded.Entries, 2)
+	require.Len(t, decoded.Trash, 1)
+	assert.Equal(t, "Old Service", decoded.Trash[0].Issuer)
+	assert.True(t, time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC).Equal(decoded.Trash[0].DeletedAt))
+
+	entries, err := DecodeVault(loadVaultFixture(t, 3))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// 過去のスキーマのデータはゴミ箱が空になる
+	decoded, err = decodeVault(loadVaultFixture(t, 2))
+	require.NoError(t, err)
+	assert.Empty(t, decoded.Trash)
+}